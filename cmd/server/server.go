@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"expenses_tracker/internal/config"
 	"expenses_tracker/internal/handler"
 	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/pkg/storage"
+	"expenses_tracker/internal/pkg/throttle"
 	"expenses_tracker/internal/repository"
+	"expenses_tracker/internal/worker"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,16 +20,68 @@ func main() {
 		panic(err)
 	}
 
-	jwtService := &jwt.JwtService{PrivateKey: cfg.Jwt.PrivateKey}
+	transactor := repository.GetTransactor(db)
 	userRepo := repository.GetUserRepository(db)
+	ledgerRepo := repository.GetLedgerRepository(db)
+	tagRepo := repository.GetTagRepository(db)
+	sessionRepo := repository.GetSessionRepository(db)
+	loginAttemptRepo := repository.GetLoginAttemptRepository(db)
 	transactionRepo := repository.GetTransactionRepository(db)
 	transactionCategoryRepo := repository.GetTransactionCategoryRepository(db)
+	exchangeRateRepo := repository.GetExchangeRateRepository(db)
+	accountRepo := repository.GetAccountRepository(db)
+	budgetRepo := repository.GetBudgetRepository(db)
+	recurringTransactionRepo := repository.GetRecurringTransactionRepository(db)
+	attachmentRepo := repository.GetAttachmentRepository(db)
+
+	attachmentStorage, err := storage.NewLocalStorage(cfg.Attachments.Dir)
+	if err != nil {
+		panic(err)
+	}
+
+	recurringWorker := worker.GetRecurringWorker(transactor, recurringTransactionRepo, transactionRepo, cfg.Recurring.Interval)
+	go recurringWorker.Run(context.Background())
+
+	trashWorker := worker.GetTrashWorker(transactor, transactionRepo, transactionCategoryRepo, attachmentRepo, attachmentStorage, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	go trashWorker.Run(context.Background())
+
+	signingKey, verificationKeys, err := jwt.LoadKeys(cfg.Jwt.SigningKeyFile, cfg.Jwt.VerificationKeyFiles)
+	if err != nil {
+		panic(err)
+	}
+	if signingKey == nil && cfg.Jwt.PrivateKey == "" {
+		panic("either JWT_SIGNING_KEY_FILE or JWT_PRIVATE_KEY has to be set")
+	}
+
+	jwtService := &jwt.JwtService{
+		PrivateKey:       cfg.Jwt.PrivateKey,
+		SigningKey:       signingKey,
+		VerificationKeys: verificationKeys,
+		AccessTokenTtl:   cfg.Jwt.AccessTokenTtl,
+		Sessions:         sessionRepo,
+	}
+
+	loginLimiter := throttle.NewLimiter(cfg.Login.MaxAttempts, cfg.Login.Lockout, cfg.Login.MaxLockout)
+	ipLimiter := throttle.NewLimiter(cfg.Login.MaxAttemptsPerIp, cfg.Login.Lockout, cfg.Login.MaxLockout)
 
 	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic(err)
+	}
 
-	handler.RegisterUserRoutes(router, jwtService, userRepo)
-	handler.RegisterTransactionRoutes(router, jwtService, transactionRepo, transactionCategoryRepo)
-	handler.RegisterTransactionCategoryRoutes(router, jwtService, transactionCategoryRepo)
+	handler.RegisterJwksRoutes(router, jwtService)
+	handler.RegisterUserRoutes(router, jwtService, transactor, userRepo, ledgerRepo, transactionCategoryRepo, sessionRepo, loginAttemptRepo, cfg.Jwt.RefreshTokenTtl, loginLimiter, ipLimiter, cfg.Admin.Logins)
+	handler.RegisterTransactionRoutes(router, jwtService, transactor, transactionRepo, transactionCategoryRepo, userRepo, accountRepo, ledgerRepo, tagRepo, cfg.Import.MaxSize)
+	handler.RegisterLedgerRoutes(router, jwtService, transactor, ledgerRepo, userRepo)
+	handler.RegisterTransactionCategoryRoutes(router, jwtService, transactor, transactionCategoryRepo, ledgerRepo, userRepo)
+	handler.RegisterTrashRoutes(router, jwtService, transactor, transactionRepo, transactionCategoryRepo, ledgerRepo, userRepo)
+	handler.RegisterAttachmentRoutes(router, jwtService, transactor, attachmentRepo, transactionRepo, ledgerRepo, userRepo, attachmentStorage, cfg.Attachments.MaxSize, cfg.Attachments.UserQuota)
+	handler.RegisterTagRoutes(router, jwtService, transactor, tagRepo, ledgerRepo, userRepo)
+	handler.RegisterAccountRoutes(router, jwtService, transactor, accountRepo, transactionRepo, userRepo)
+	handler.RegisterBudgetRoutes(router, jwtService, budgetRepo, transactionRepo, transactionCategoryRepo, userRepo, ledgerRepo)
+	handler.RegisterRecurringTransactionRoutes(router, jwtService, transactor, recurringTransactionRepo, transactionCategoryRepo, accountRepo, userRepo, ledgerRepo)
+	handler.RegisterBalanceRoutes(router, jwtService, transactor, transactionRepo, ledgerRepo, userRepo)
+	handler.RegisterExchangeRateRoutes(router, jwtService, transactor, exchangeRateRepo, userRepo, cfg.Admin.Logins)
 
 	router.Run()
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 )
@@ -10,13 +12,73 @@ type DbConfig struct {
 }
 
 type JwtConfig struct {
-	PrivateKey string `envconfig:"JWT_PRIVATE_KEY" required:"true"`
+	// PrivateKey is the HS256 secret, used to sign tokens when no signing key
+	// file is set, and to verify older tokens otherwise.
+	PrivateKey string `envconfig:"JWT_PRIVATE_KEY"`
+	// PEM files with an RSA or Ed25519 private key to sign tokens with, and with
+	// public keys of previous signing keys that are still accepted.
+	SigningKeyFile       string        `envconfig:"JWT_SIGNING_KEY_FILE"`
+	VerificationKeyFiles []string      `envconfig:"JWT_VERIFICATION_KEY_FILES"`
+	AccessTokenTtl       time.Duration `envconfig:"JWT_ACCESS_TOKEN_TTL" default:"15m"`
+	RefreshTokenTtl      time.Duration `envconfig:"JWT_REFRESH_TOKEN_TTL" default:"720h"`
+}
+
+type AdminConfig struct {
+	// Logins of users allowed to manage server-wide data such as exchange rates.
+	// Registration rejects these logins, so only list accounts that already
+	// exist and belong to the operators.
+	Logins []string `envconfig:"ADMIN_LOGINS"`
+}
+
+type LoginConfig struct {
+	// Failed attempts allowed before a login or an IP address gets locked out.
+	MaxAttempts      int `envconfig:"LOGIN_MAX_ATTEMPTS" default:"5"`
+	MaxAttemptsPerIp int `envconfig:"LOGIN_MAX_ATTEMPTS_PER_IP" default:"20"`
+	// The first lockout, every next failure doubles it up to MaxLockout.
+	Lockout    time.Duration `envconfig:"LOGIN_LOCKOUT" default:"30s"`
+	MaxLockout time.Duration `envconfig:"LOGIN_MAX_LOCKOUT" default:"1h"`
+}
+
+type ImportConfig struct {
+	// Largest CSV import request accepted, in bytes.
+	MaxSize int64 `envconfig:"IMPORT_MAX_SIZE" default:"5242880"`
+}
+
+type RecurringConfig struct {
+	// How often due recurring transactions are posted.
+	Interval time.Duration `envconfig:"RECURRING_INTERVAL" default:"1m"`
+}
+
+type TrashConfig struct {
+	// How long deleted transactions and categories can be restored, and how
+	// often the ones past it are purged.
+	Retention     time.Duration `envconfig:"TRASH_RETENTION" default:"720h"`
+	PurgeInterval time.Duration `envconfig:"TRASH_PURGE_INTERVAL" default:"1h"`
+}
+
+type AttachmentConfig struct {
+	// Directory the uploaded files are kept in.
+	Dir string `envconfig:"ATTACHMENTS_DIR" default:"attachments"`
+	// Largest file accepted, and how much space the files of one user can take,
+	// in bytes.
+	MaxSize   int64 `envconfig:"ATTACHMENTS_MAX_SIZE" default:"10485760"`
+	UserQuota int64 `envconfig:"ATTACHMENTS_USER_QUOTA" default:"104857600"`
 }
 
 type Config struct {
-	Port int `envconfig:"PORT" required:"true"`
-	DB   DbConfig
-	Jwt  JwtConfig
+	Port        int `envconfig:"PORT" required:"true"`
+	DB          DbConfig
+	Jwt         JwtConfig
+	Admin       AdminConfig
+	Login       LoginConfig
+	Import      ImportConfig
+	Recurring   RecurringConfig
+	Trash       TrashConfig
+	Attachments AttachmentConfig
+
+	// Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For header
+	// is believed. Without any, clients are told apart by the peer address.
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
 }
 
 func GetConfigFromEnv(path string) Config {
@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/currency"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/pkg/utils"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type accountHandler struct {
+	transactor            repository.Transactor
+	accountRepository     repository.AccountRepository
+	transactionRepository repository.TransactionRepository
+	userRepository        repository.UserRepository
+}
+
+func RegisterAccountRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, accountRepository repository.AccountRepository, transactionRepository repository.TransactionRepository, userRepository repository.UserRepository) {
+	handler := accountHandler{
+		transactor:            transactor,
+		accountRepository:     accountRepository,
+		transactionRepository: transactionRepository,
+		userRepository:        userRepository,
+	}
+
+	accountRouterGroup := router.Group("/account").Use(auth.GetAuthMiddleware(jwtService))
+
+	accountRouterGroup.POST("", handler.create)
+	accountRouterGroup.GET("", handler.get)
+	accountRouterGroup.PUT("", handler.update)
+	accountRouterGroup.DELETE("", handler.deleteAccount)
+
+	accountRouterGroup.GET("/statement", handler.getStatement)
+	accountRouterGroup.POST("/transfer", handler.transfer)
+}
+
+func (h *accountHandler) create(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var account model.Account
+	if err := c.BindJSON(&account); err != nil || account.Name == "" || !model.IsValidAccountType(account.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account object"})
+		return
+	}
+
+	if account.Currency == "" {
+		user, err := h.userRepository.FindById(userId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		account.Currency = user.BaseCurrency
+	}
+
+	var err error
+	account.Currency, err = currency.Normalize(account.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account.UserId = userId
+	id, err := h.accountRepository.CreateAccount(account)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "can't create account",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "ok",
+		"id":     id,
+	})
+}
+
+func (h *accountHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	accounts, err := h.accountRepository.GetAccounts(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (h *accountHandler) update(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateAccountInput struct {
+		AccountId      int64  `json:"id" binding:"required"`
+		Name           string `json:"name"`
+		Type           string `json:"type"`
+		OpeningBalance *int64 `json:"openingBalance"`
+	}
+
+	var input UpdateAccountInput
+	if err := c.BindJSON(&input); err != nil || input.AccountId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	account, err := h.accountRepository.GetAccountById(input.AccountId)
+	if err != nil || account.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	if input.Name != "" {
+		account.Name = input.Name
+	}
+	if input.Type != "" {
+		if !model.IsValidAccountType(input.Type) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid account type"})
+			return
+		}
+		account.Type = input.Type
+	}
+	if input.OpeningBalance != nil {
+		account.OpeningBalance = *input.OpeningBalance
+	}
+
+	err = h.accountRepository.UpdateAccount(account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *accountHandler) deleteAccount(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type DeleteAccountInput struct {
+		AccountId int64 `json:"id" binding:"required"`
+	}
+
+	var input DeleteAccountInput
+	if err := c.BindJSON(&input); err != nil || input.AccountId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	account, err := h.accountRepository.GetAccountById(input.AccountId)
+	if err != nil || account.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	inUse, err := h.accountRepository.HasTransactions(account.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		return
+	}
+	if inUse {
+		c.JSON(http.StatusConflict, gin.H{"error": "account has transactions"})
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		return h.accountRepository.WithTx(tx).DeleteAccount(account.Id, userId)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *accountHandler) getStatement(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	accountId, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing id parameter"})
+		return
+	}
+
+	page, err := strconv.ParseInt(c.Query("page"), 10, 64)
+	if err != nil || page <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing page parameter"})
+		return
+	}
+
+	items, err := strconv.ParseInt(c.Query("items"), 10, 64)
+	if err != nil || items <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing items parameter"})
+		return
+	}
+
+	account, err := h.accountRepository.GetAccountById(accountId)
+	if err != nil || account.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	pagination := repository.Pagination{
+		Page:  page,
+		Items: items,
+	}
+
+	statement, err := h.accountRepository.GetAccountStatement(account.Id, repository.ResolvePagination(&pagination))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch statement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+func (h *accountHandler) transfer(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type TransferInput struct {
+		FromAccountId int64  `json:"fromAccountId" binding:"required"`
+		ToAccountId   int64  `json:"toAccountId" binding:"required"`
+		Amount        int64  `json:"amount" binding:"required"`
+		Date          string `json:"date"`
+		Description   string `json:"description"`
+	}
+
+	var input TransferInput
+	if err := c.BindJSON(&input); err != nil || input.Amount <= 0 || input.FromAccountId == input.ToAccountId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer object"})
+		return
+	}
+
+	if input.Date != "" {
+		if _, err := utils.ParseDate(input.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date, expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+	}
+
+	fromAccount, err := h.accountRepository.GetAccountById(input.FromAccountId)
+	if err != nil || fromAccount.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	toAccount, err := h.accountRepository.GetAccountById(input.ToAccountId)
+	if err != nil || toAccount.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	if fromAccount.Currency != toAccount.Currency {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "accounts must have the same currency"})
+		return
+	}
+
+	from := model.Transaction{
+		Kind:        model.TransactionKindTransfer,
+		Price:       -input.Amount,
+		Currency:    fromAccount.Currency,
+		AccountId:   &fromAccount.Id,
+		Date:        input.Date,
+		Description: input.Description,
+		UserId:      userId,
+	}
+	to := from
+	to.Price = input.Amount
+	to.AccountId = &toAccount.Id
+
+	var transferId int64
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		var err error
+		transferId, err = h.transactionRepository.WithTx(tx).CreateTransfer(from, to)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create transfer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "ok",
+		"transferId": transferId,
+	})
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/pkg/storage"
+	"expenses_tracker/internal/repository"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Room left in an upload request for the multipart framing around the file.
+const multipartOverhead = 64 << 10
+
+const maxFileNameLength = 255
+
+var errQuotaExceeded = errors.New("attachment quota exceeded")
+
+type attachmentHandler struct {
+	transactor            repository.Transactor
+	attachmentRepository  repository.AttachmentRepository
+	transactionRepository repository.TransactionRepository
+	storage               storage.Storage
+	ledgers               ledgerAccess
+	maxSize               int64
+	userQuota             int64
+}
+
+func RegisterAttachmentRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, attachmentRepository repository.AttachmentRepository, transactionRepository repository.TransactionRepository, ledgerRepository repository.LedgerRepository, userRepository repository.UserRepository, fileStorage storage.Storage, maxSize int64, userQuota int64) {
+	handler := attachmentHandler{
+		transactor:            transactor,
+		attachmentRepository:  attachmentRepository,
+		transactionRepository: transactionRepository,
+		storage:               fileStorage,
+		ledgers:               ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+		maxSize:               maxSize,
+		userQuota:             userQuota,
+	}
+
+	attachmentRouterGroup := router.Group("/transaction/:id/attachments").Use(auth.GetAuthMiddleware(jwtService))
+
+	attachmentRouterGroup.POST("", handler.upload)
+	attachmentRouterGroup.GET("", handler.get)
+	attachmentRouterGroup.GET("/:attachmentId", handler.download)
+	attachmentRouterGroup.DELETE("/:attachmentId", handler.deleteAttachment)
+}
+
+// transaction finds the transaction of the request and checks the user has at
+// least minRole in its ledger. Transactions in the trash are not found, so
+// their attachments stay hidden until they are restored or purged.
+func (h *attachmentHandler) transaction(c *gin.Context, userId int64, minRole string) (model.Transaction, bool) {
+	transactionId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction id"})
+		return model.Transaction{}, false
+	}
+
+	transaction, err := h.transactionRepository.GetTransactionById(transactionId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return model.Transaction{}, false
+	}
+	if !h.ledgers.check(c, transaction.LedgerId, userId, minRole) {
+		return model.Transaction{}, false
+	}
+
+	return transaction, true
+}
+
+func (h *attachmentHandler) attachment(c *gin.Context, transactionId int64) (model.Attachment, bool) {
+	attachmentId, err := strconv.ParseInt(c.Param("attachmentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return model.Attachment{}, false
+	}
+
+	attachment, err := h.attachmentRepository.GetAttachmentById(attachmentId)
+	if err != nil || attachment.TransactionId != transactionId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return model.Attachment{}, false
+	}
+
+	return attachment, true
+}
+
+// upload attaches the file in the file field of a multipart form. The content
+// type is sniffed from the file itself, and identical files are stored once.
+func (h *attachmentHandler) upload(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transaction, ok := h.transaction(c, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide a file in the file field"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read file"})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read file"})
+		return
+	}
+	if int64(len(content)) > h.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+		return
+	}
+	if len(content) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is empty"})
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	if !model.IsAllowedAttachmentType(contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "only images and pdf files can be attached"})
+		return
+	}
+
+	sum := sha256.Sum256(content)
+	attachment := model.Attachment{
+		TransactionId: transaction.Id,
+		UserId:        userId,
+		FileName:      attachmentFileName(fileHeader.Filename),
+		ContentType:   contentType,
+		Size:          int64(len(content)),
+		Hash:          hex.EncodeToString(sum[:]),
+	}
+
+	// The lock keeps the file from being deleted as unused between storing it
+	// and committing the attachment that uses it.
+	unlock := h.storage.Lock(attachment.Hash)
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		attachmentRepository := h.attachmentRepository.WithTx(tx)
+
+		stored, err := attachmentRepository.HasUserHash(userId, attachment.Hash)
+		if err != nil {
+			return err
+		}
+		if !stored {
+			usage, err := attachmentRepository.GetUserUsage(userId)
+			if err != nil {
+				return err
+			}
+			if usage+attachment.Size > h.userQuota {
+				return errQuotaExceeded
+			}
+		}
+
+		if err := h.storage.Put(attachment.Hash, bytes.NewReader(content)); err != nil {
+			return err
+		}
+
+		attachment.Id, err = attachmentRepository.CreateAttachment(attachment)
+		return err
+	})
+	unlock()
+	if errors.Is(err, errQuotaExceeded) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "attachment storage quota exceeded"})
+		return
+	}
+	if err != nil {
+		h.deleteUnusedFile(attachment.Hash)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save attachment"})
+		return
+	}
+
+	attachment, err = h.attachmentRepository.GetAttachmentById(attachment.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch attachment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+func (h *attachmentHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transaction, ok := h.transaction(c, userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentRepository.GetAttachments(transaction.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+func (h *attachmentHandler) download(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transaction, ok := h.transaction(c, userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+	attachment, ok := h.attachment(c, transaction.Id)
+	if !ok {
+		return
+	}
+
+	file, err := h.storage.Open(attachment.Hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read attachment"})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (h *attachmentHandler) deleteAttachment(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transaction, ok := h.transaction(c, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+	attachment, ok := h.attachment(c, transaction.Id)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentRepository.DeleteAttachment(attachment.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		return
+	}
+	h.deleteUnusedFile(attachment.Hash)
+
+	c.String(http.StatusOK, "OK")
+}
+
+// deleteUnusedFile removes a stored file once no attachment points at it. A
+// failure only leaves the file behind, so it is logged rather than returned.
+func (h *attachmentHandler) deleteUnusedFile(hash string) {
+	unlock := h.storage.Lock(hash)
+	defer unlock()
+
+	used, err := h.attachmentRepository.IsHashUsed(hash)
+	if err == nil && !used {
+		err = h.storage.Delete(hash)
+	}
+	if err != nil {
+		log.Println("attachments:", err)
+	}
+}
+
+func attachmentFileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+	if len(name) > maxFileNameLength {
+		name = strings.ToValidUTF8(name[:maxFileNameLength], "")
+	}
+	return name
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/currency"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/pkg/split"
+	"expenses_tracker/internal/pkg/utils"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type balanceHandler struct {
+	transactor            repository.Transactor
+	transactionRepository repository.TransactionRepository
+	ledgers               ledgerAccess
+}
+
+func RegisterBalanceRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, transactionRepository repository.TransactionRepository, ledgerRepository repository.LedgerRepository, userRepository repository.UserRepository) {
+	handler := balanceHandler{
+		transactor:            transactor,
+		transactionRepository: transactionRepository,
+		ledgers:               ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+	}
+
+	balanceRouterGroup := router.Group("/balances").Use(auth.GetAuthMiddleware(jwtService))
+
+	balanceRouterGroup.GET("", handler.get)
+	balanceRouterGroup.POST("/settle", handler.settle)
+}
+
+type splitPartInput struct {
+	UserId  int64   `json:"userId" binding:"required"`
+	Amount  int64   `json:"amount"`
+	Percent float64 `json:"percent"`
+}
+
+type splitInput struct {
+	Mode  string           `json:"mode"`
+	Parts []splitPartInput `json:"parts"`
+}
+
+// resolveSplits turns the split of a transaction into the amount every member
+// owes. It responds with an error and returns false when the split is invalid
+// or names users outside the ledger.
+func resolveSplits(c *gin.Context, ledgers ledgerAccess, ledgerId int64, price int64, input splitInput) ([]model.TransactionSplit, bool) {
+	if input.Mode == "" {
+		input.Mode = split.Equal
+	}
+
+	parts := make([]split.Part, len(input.Parts))
+	for i, part := range input.Parts {
+		if !ledgers.hasRole(ledgerId, part.UserId, model.LedgerRoleViewer) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "split users must be members of the ledger"})
+			return nil, false
+		}
+		parts[i] = split.Part{UserId: part.UserId, Amount: part.Amount, Percent: part.Percent}
+	}
+
+	amounts, err := split.Amounts(price, input.Mode, parts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	splits := make([]model.TransactionSplit, len(parts))
+	for i, part := range parts {
+		splits[i] = model.TransactionSplit{UserId: part.UserId, Amount: amounts[i]}
+	}
+
+	return splits, true
+}
+
+func (h *balanceHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	balances, err := h.transactionRepository.GetBalances(ledgerId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch balances"})
+		return
+	}
+
+	byCurrency := map[string]map[int64]int64{}
+	currencies := []string{}
+	for _, balance := range balances {
+		if byCurrency[balance.Currency] == nil {
+			byCurrency[balance.Currency] = map[int64]int64{}
+			currencies = append(currencies, balance.Currency)
+		}
+		byCurrency[balance.Currency][balance.UserId] = balance.Amount
+	}
+
+	debts := []model.Debt{}
+	for _, code := range currencies {
+		for _, debt := range split.Simplify(byCurrency[code]) {
+			debts = append(debts, model.Debt{
+				FromUserId: debt.From,
+				ToUserId:   debt.To,
+				Currency:   code,
+				Amount:     debt.Amount,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, model.LedgerBalances{
+		LedgerId: ledgerId,
+		Balances: balances,
+		Debts:    debts,
+	})
+}
+
+// settle records a payment from the user to another ledger member, which
+// lowers what the user owes them by its amount.
+func (h *balanceHandler) settle(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type SettleInput struct {
+		LedgerId    int64  `json:"ledgerId"`
+		ToUserId    int64  `json:"toUserId" binding:"required"`
+		Amount      int64  `json:"amount" binding:"required"`
+		Currency    string `json:"currency"`
+		Date        string `json:"date"`
+		Description string `json:"description"`
+	}
+
+	var input SettleInput
+	if err := c.BindJSON(&input); err != nil || input.Amount <= 0 || input.ToUserId == userId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid settlement object"})
+		return
+	}
+
+	ledgerIdParam := ""
+	if input.LedgerId != 0 {
+		ledgerIdParam = strconv.FormatInt(input.LedgerId, 10)
+	}
+	ledgerId, ok := h.ledgers.resolve(c, ledgerIdParam, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+
+	if !h.ledgers.hasRole(ledgerId, input.ToUserId, model.LedgerRoleViewer) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the user must be a member of the ledger"})
+		return
+	}
+
+	if input.Date != "" {
+		if _, err := utils.ParseDate(input.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date, expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+	}
+
+	if input.Currency != "" {
+		var err error
+		input.Currency, err = currency.Normalize(input.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	settlement := model.Transaction{
+		Kind:        model.TransactionKindSettlement,
+		Price:       input.Amount,
+		Currency:    input.Currency,
+		Date:        input.Date,
+		Description: input.Description,
+		LedgerId:    ledgerId,
+		UserId:      userId,
+	}
+
+	var id int64
+	err := h.transactor.InTransaction(func(tx *sql.Tx) error {
+		transactionRepository := h.transactionRepository.WithTx(tx)
+
+		var err error
+		id, err = transactionRepository.CreateTransaction(settlement)
+		if err != nil {
+			return err
+		}
+		return transactionRepository.SetSplits(id, []model.TransactionSplit{{UserId: input.ToUserId, Amount: input.Amount}})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create settlement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"id":     id,
+	})
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/repository"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRolloverMonths limits how far back unspent amounts are carried over.
+const maxRolloverMonths = 24
+
+type budgetHandler struct {
+	budgetRepository              repository.BudgetRepository
+	transactionRepository         repository.TransactionRepository
+	transactionCategoryRepository repository.TransactionCategoryRepository
+	userRepository                repository.UserRepository
+	ledgers                       ledgerAccess
+}
+
+func RegisterBudgetRoutes(router *gin.Engine, jwtService *jwt.JwtService, budgetRepository repository.BudgetRepository, transactionRepository repository.TransactionRepository, transactionCategoryRepository repository.TransactionCategoryRepository, userRepository repository.UserRepository, ledgerRepository repository.LedgerRepository) {
+	handler := budgetHandler{
+		budgetRepository:              budgetRepository,
+		transactionRepository:         transactionRepository,
+		transactionCategoryRepository: transactionCategoryRepository,
+		userRepository:                userRepository,
+		ledgers:                       ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+	}
+
+	budgetRouterGroup := router.Group("/budget").Use(auth.GetAuthMiddleware(jwtService))
+
+	budgetRouterGroup.POST("", handler.create)
+	budgetRouterGroup.GET("", handler.get)
+	budgetRouterGroup.PUT("", handler.update)
+	budgetRouterGroup.DELETE("", handler.deleteBudget)
+
+	budgetRouterGroup.GET("/status", handler.getStatus)
+}
+
+func (h *budgetHandler) create(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var budget model.Budget
+	if err := c.BindJSON(&budget); err != nil || budget.CategoryId == 0 || budget.Year == 0 || budget.Month < 1 || budget.Month > 12 || budget.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid budget object"})
+		return
+	}
+
+	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(budget.CategoryId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleViewer) {
+		return
+	}
+
+	budget.UserId = userId
+	id, err := h.budgetRepository.CreateBudget(budget)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "can't create budget, there may already be one for this category and month",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "ok",
+		"id":     id,
+	})
+}
+
+func (h *budgetHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	year, month, err := parseBudgetPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budgets, err := h.budgetRepository.GetBudgets(userId, year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch budgets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+func (h *budgetHandler) update(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateBudgetInput struct {
+		BudgetId int64 `json:"id" binding:"required"`
+		Amount   int64 `json:"amount"`
+		Rollover *bool `json:"rollover"`
+	}
+
+	var input UpdateBudgetInput
+	if err := c.BindJSON(&input); err != nil || input.BudgetId == 0 || input.Amount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	budget, err := h.budgetRepository.GetBudgetById(input.BudgetId)
+	if err != nil || budget.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	if input.Amount != 0 {
+		budget.Amount = input.Amount
+	}
+	if input.Rollover != nil {
+		budget.Rollover = *input.Rollover
+	}
+
+	err = h.budgetRepository.UpdateBudget(budget)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *budgetHandler) deleteBudget(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type DeleteBudgetInput struct {
+		BudgetId int64 `json:"id" binding:"required"`
+	}
+
+	var input DeleteBudgetInput
+	if err := c.BindJSON(&input); err != nil || input.BudgetId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	budget, err := h.budgetRepository.GetBudgetById(input.BudgetId)
+	if err != nil || budget.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	err = h.budgetRepository.DeleteBudget(budget.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *budgetHandler) getStatus(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	year, month, err := parseBudgetPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userRepository.FindById(userId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	budgets, err := h.budgetRepository.GetBudgets(userId, year, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch budgets"})
+		return
+	}
+
+	statuses := []model.BudgetStatus{}
+	for _, budget := range budgets {
+		category, err := h.transactionCategoryRepository.GetTransactionCategoryById(budget.CategoryId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute budget status"})
+			return
+		}
+		// Budgets outlive the membership in the ledger of their category, the
+		// spendings of a ledger the user left are none of their business.
+		if !h.ledgers.hasRole(category.LedgerId, userId, model.LedgerRoleViewer) {
+			continue
+		}
+
+		status, err := h.getBudgetStatus(budget, category.LedgerId, user.BaseCurrency, maxRolloverMonths)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute budget status"})
+			return
+		}
+		statuses = append(statuses, status)
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+// getBudgetStatus computes how much of the budget is spent, counting spendings
+// of all members of the category ledger. Budgets are in the user's base
+// currency, so spendings are converted into it. When the previous
+// month budget of the same category has rollover enabled, its unspent part is
+// added to the limit, following the chain back for at most depth months.
+func (h *budgetHandler) getBudgetStatus(budget model.Budget, ledgerId int64, baseCurrency string, depth int) (model.BudgetStatus, error) {
+	status := model.BudgetStatus{
+		BudgetId:   budget.Id,
+		CategoryId: budget.CategoryId,
+		Amount:     budget.Amount,
+	}
+
+	total, err := h.transactionRepository.GetTotalPriceByDateAndCategory(ledgerId, repository.TotalFilter{
+		Year:       budget.Year,
+		Month:      budget.Month,
+		CategoryId: budget.CategoryId,
+		ConvertTo:  baseCurrency,
+	})
+	if err != nil {
+		return status, err
+	}
+	status.Spent = total.Expense
+	status.MissingRates = total.MissingRates
+
+	if depth > 0 {
+		previousYear, previousMonth := budget.Year, budget.Month-1
+		if previousMonth == 0 {
+			previousYear, previousMonth = previousYear-1, 12
+		}
+
+		previous, err := h.budgetRepository.GetBudgetForPeriod(budget.UserId, budget.CategoryId, previousYear, previousMonth)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return status, err
+		}
+
+		if err == nil && previous.Rollover {
+			previousStatus, err := h.getBudgetStatus(previous, ledgerId, baseCurrency, depth-1)
+			if err != nil {
+				return status, err
+			}
+			status.RolledOver = math.Max(previousStatus.Remaining, 0)
+			status.MissingRates += previousStatus.MissingRates
+		}
+	}
+
+	status.Limit = float64(status.Amount) + status.RolledOver
+	status.Remaining = status.Limit - status.Spent
+	status.OverBudget = status.Remaining < 0
+	if status.Limit > 0 {
+		status.Percent = math.Round(status.Spent/status.Limit*10000) / 100
+	}
+
+	return status, nil
+}
+
+func parseBudgetPeriod(c *gin.Context) (int, int, error) {
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil || year == 0 {
+		return 0, 0, errors.New("invalid year parameter")
+	}
+
+	month, err := strconv.Atoi(c.Query("month"))
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, errors.New("invalid month parameter")
+	}
+
+	return year, month, nil
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/currency"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/repository"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exchangeRateHandler struct {
+	transactor             repository.Transactor
+	exchangeRateRepository repository.ExchangeRateRepository
+	userRepository         repository.UserRepository
+	adminLogins            []string
+}
+
+func RegisterExchangeRateRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, exchangeRateRepository repository.ExchangeRateRepository, userRepository repository.UserRepository, adminLogins []string) {
+	handler := exchangeRateHandler{
+		transactor:             transactor,
+		exchangeRateRepository: exchangeRateRepository,
+		userRepository:         userRepository,
+		adminLogins:            adminLogins,
+	}
+
+	exchangeRateRouterGroup := router.Group("/exchange-rate").Use(auth.GetAuthMiddleware(jwtService))
+
+	exchangeRateRouterGroup.GET("", handler.get)
+	exchangeRateRouterGroup.POST("/import", handler.importRates)
+}
+
+func (h *exchangeRateHandler) get(c *gin.Context) {
+	if _, ok := auth.GetUserId(c); !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var currencyCode string
+	if currencyParam := c.Query("currency"); currencyParam != "" {
+		var err error
+		currencyCode, err = currency.Normalize(currencyParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	page, err := strconv.ParseInt(c.Query("page"), 10, 64)
+	if err != nil || page <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing page parameter"})
+		return
+	}
+
+	items, err := strconv.ParseInt(c.Query("items"), 10, 64)
+	if err != nil || items <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing items parameter"})
+		return
+	}
+
+	pagination := repository.Pagination{
+		Page:  page,
+		Items: items,
+	}
+
+	rates, err := h.exchangeRateRepository.GetExchangeRates(currencyCode, repository.ResolvePagination(&pagination))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+func (h *exchangeRateHandler) importRates(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := h.userRepository.FindById(userId)
+	if err != nil || !slices.Contains(h.adminLogins, user.Login) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can load exchange rates"})
+		return
+	}
+
+	var rates []model.ExchangeRate
+	if err := c.BindJSON(&rates); err != nil || len(rates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid exchange rates list"})
+		return
+	}
+
+	for i := range rates {
+		if err := normalizeExchangeRate(&rates[i]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("rate %d: %s", i, err.Error())})
+			return
+		}
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		exchangeRateRepository := h.exchangeRateRepository.WithTx(tx)
+		for _, rate := range rates {
+			if err := exchangeRateRepository.SaveExchangeRate(rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": len(rates)})
+}
+
+func normalizeExchangeRate(rate *model.ExchangeRate) error {
+	date, err := time.Parse("2006-01-02", rate.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", rate.Date)
+	}
+	rate.Date = date.Format("2006-01-02")
+
+	if rate.From, err = currency.Normalize(rate.From); err != nil {
+		return err
+	}
+	if rate.To, err = currency.Normalize(rate.To); err != nil {
+		return err
+	}
+	if rate.From == rate.To {
+		return errors.New("from and to currencies must differ")
+	}
+	if rate.Rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"expenses_tracker/internal/pkg/jwt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterJwksRoutes(router *gin.Engine, jwtService *jwt.JwtService) {
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, jwtService.Jwks())
+	})
+}
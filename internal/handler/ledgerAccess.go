@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ledgerAccess checks what members may do with data of a ledger. Handlers
+// use it instead of comparing owners of categories and transactions.
+type ledgerAccess struct {
+	ledgerRepository repository.LedgerRepository
+	userRepository   repository.UserRepository
+}
+
+func (a ledgerAccess) hasRole(ledgerId int64, userId int64, minRole string) bool {
+	role, err := a.ledgerRepository.GetMemberRole(ledgerId, userId)
+	return err == nil && model.HasLedgerRole(role, minRole)
+}
+
+// check responds with 404 when the user is not a member of the ledger and
+// with 403 when their role is below minRole, and tells whether to go on.
+func (a ledgerAccess) check(c *gin.Context, ledgerId int64, userId int64, minRole string) bool {
+	if status, message := a.status(ledgerId, userId, minRole); status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return false
+	}
+
+	return true
+}
+
+// status works like check, but returns the status and message of the response
+// to send on failure instead of sending it.
+func (a ledgerAccess) status(ledgerId int64, userId int64, minRole string) (int, string) {
+	role, err := a.ledgerRepository.GetMemberRole(ledgerId, userId)
+	if err != nil {
+		role = ""
+	}
+	return ledgerRoleStatus(role, minRole)
+}
+
+// ledgerRoleStatus checks a role that is already known, an empty one meaning
+// that the user is not a member.
+func ledgerRoleStatus(role string, minRole string) (int, string) {
+	if role == "" {
+		return http.StatusNotFound, "not found"
+	}
+	if !model.HasLedgerRole(role, minRole) {
+		return http.StatusForbidden, "your role in this ledger does not allow this"
+	}
+
+	return 0, ""
+}
+
+// resolve returns the ledger named by value, or the user's default ledger when
+// value is empty, after checking the user's role in it like check does.
+func (a ledgerAccess) resolve(c *gin.Context, value string, userId int64, minRole string) (int64, bool) {
+	if value == "" {
+		user, err := a.userRepository.FindById(userId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return 0, false
+		}
+		return user.DefaultLedgerId, a.check(c, user.DefaultLedgerId, userId, minRole)
+	}
+
+	ledgerId, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ledgerId <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ledgerId parameter"})
+		return 0, false
+	}
+
+	return ledgerId, a.check(c, ledgerId, userId, minRole)
+}
@@ -0,0 +1,413 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ledgerHandler struct {
+	transactor       repository.Transactor
+	ledgerRepository repository.LedgerRepository
+	userRepository   repository.UserRepository
+	ledgers          ledgerAccess
+}
+
+func RegisterLedgerRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, ledgerRepository repository.LedgerRepository, userRepository repository.UserRepository) {
+	handler := ledgerHandler{
+		transactor:       transactor,
+		ledgerRepository: ledgerRepository,
+		userRepository:   userRepository,
+		ledgers:          ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+	}
+
+	ledgerRouterGroup := router.Group("/ledger").Use(auth.GetAuthMiddleware(jwtService))
+
+	ledgerRouterGroup.POST("", handler.create)
+	ledgerRouterGroup.GET("", handler.get)
+	ledgerRouterGroup.PUT("", handler.update)
+
+	ledgerRouterGroup.GET("/member", handler.getMembers)
+	ledgerRouterGroup.PUT("/member", handler.updateMember)
+	ledgerRouterGroup.DELETE("/member", handler.removeMember)
+
+	ledgerRouterGroup.POST("/invitation", handler.invite)
+	ledgerRouterGroup.GET("/invitation", handler.getInvitations)
+	ledgerRouterGroup.POST("/invitation/accept", handler.acceptInvitation)
+	ledgerRouterGroup.DELETE("/invitation", handler.deleteInvitation)
+}
+
+func (h *ledgerHandler) create(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type CreateLedgerInput struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	var input CreateLedgerInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ledger object"})
+		return
+	}
+
+	var id int64
+	err := h.transactor.InTransaction(func(tx *sql.Tx) error {
+		var err error
+		id, err = h.ledgerRepository.WithTx(tx).CreateLedger(input.Name, userId)
+		return err
+	})
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "can't create ledger",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "ok",
+		"id":     id,
+	})
+}
+
+func (h *ledgerHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ledgers, err := h.ledgerRepository.GetLedgers(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch ledgers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ledgers)
+}
+
+func (h *ledgerHandler) update(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateLedgerInput struct {
+		LedgerId int64  `json:"id" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+	}
+
+	var input UpdateLedgerInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	if !h.ledgers.check(c, input.LedgerId, userId, model.LedgerRoleOwner) {
+		return
+	}
+
+	if err := h.ledgerRepository.UpdateLedger(input.LedgerId, input.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *ledgerHandler) getMembers(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ledgerId, err := strconv.ParseInt(c.Query("ledgerId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing ledgerId parameter"})
+		return
+	}
+
+	if !h.ledgers.check(c, ledgerId, userId, model.LedgerRoleViewer) {
+		return
+	}
+
+	members, err := h.ledgerRepository.GetMembers(ledgerId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *ledgerHandler) updateMember(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateMemberInput struct {
+		LedgerId int64  `json:"ledgerId" binding:"required"`
+		UserId   int64  `json:"userId" binding:"required"`
+		Role     string `json:"role" binding:"required"`
+	}
+
+	var input UpdateMemberInput
+	if err := c.BindJSON(&input); err != nil || !model.IsValidLedgerRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	if !h.ledgers.check(c, input.LedgerId, userId, model.LedgerRoleOwner) {
+		return
+	}
+
+	role, err := h.ledgerRepository.GetMemberRole(input.LedgerId, input.UserId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	if role == model.LedgerRoleOwner && input.Role != model.LedgerRoleOwner {
+		if status, message := h.checkOtherOwners(input.LedgerId); status != 0 {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+	}
+
+	if err := h.ledgerRepository.UpdateMemberRole(input.LedgerId, input.UserId, input.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// removeMember lets owners remove anyone and other members leave the ledger.
+// Nobody can leave their default ledger.
+func (h *ledgerHandler) removeMember(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type RemoveMemberInput struct {
+		LedgerId int64 `json:"ledgerId" binding:"required"`
+		UserId   int64 `json:"userId" binding:"required"`
+	}
+
+	var input RemoveMemberInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	minRole := model.LedgerRoleOwner
+	if input.UserId == userId {
+		minRole = model.LedgerRoleViewer
+	}
+	if !h.ledgers.check(c, input.LedgerId, userId, minRole) {
+		return
+	}
+
+	role, err := h.ledgerRepository.GetMemberRole(input.LedgerId, input.UserId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	member, err := h.userRepository.FindById(input.UserId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if member.DefaultLedgerId == input.LedgerId {
+		c.JSON(http.StatusConflict, gin.H{"error": "the default ledger of a user can't be left"})
+		return
+	}
+
+	if role == model.LedgerRoleOwner {
+		if status, message := h.checkOtherOwners(input.LedgerId); status != 0 {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+	}
+
+	if err := h.ledgerRepository.RemoveMember(input.LedgerId, input.UserId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove member"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// checkOtherOwners makes sure the ledger keeps an owner when one of its owners
+// leaves or loses the role.
+func (h *ledgerHandler) checkOtherOwners(ledgerId int64) (int, string) {
+	owners, err := h.ledgerRepository.CountOwners(ledgerId)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to count owners"
+	}
+	if owners <= 1 {
+		return http.StatusConflict, "ledger must keep at least one owner"
+	}
+	return 0, ""
+}
+
+func (h *ledgerHandler) invite(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type InviteInput struct {
+		LedgerId int64  `json:"ledgerId" binding:"required"`
+		Login    string `json:"login" binding:"required"`
+		Role     string `json:"role"`
+	}
+
+	var input InviteInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	if input.Role == "" {
+		input.Role = model.LedgerRoleEditor
+	}
+	if !model.IsValidLedgerRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role, expected owner, editor or viewer"})
+		return
+	}
+
+	if !h.ledgers.check(c, input.LedgerId, userId, model.LedgerRoleOwner) {
+		return
+	}
+
+	invitee, err := h.userRepository.FindByLogin(input.Login)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if _, err := h.ledgerRepository.GetMemberRole(input.LedgerId, invitee.Id); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is already a member"})
+		return
+	}
+
+	id, err := h.ledgerRepository.CreateInvitation(model.LedgerInvitation{
+		LedgerId: input.LedgerId,
+		UserId:   invitee.Id,
+		Role:     input.Role,
+	}, userId)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is already invited"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "ok",
+		"id":     id,
+	})
+}
+
+func (h *ledgerHandler) getInvitations(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	invitations, err := h.ledgerRepository.GetInvitations(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *ledgerHandler) acceptInvitation(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type AcceptInvitationInput struct {
+		InvitationId int64 `json:"id" binding:"required"`
+	}
+
+	var input AcceptInvitationInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	invitation, err := h.ledgerRepository.GetInvitationById(input.InvitationId)
+	if err != nil || invitation.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		ledgerRepository := h.ledgerRepository.WithTx(tx)
+		if err := ledgerRepository.AddMember(invitation.LedgerId, userId, invitation.Role); err != nil {
+			return err
+		}
+		return ledgerRepository.DeleteInvitation(invitation.Id)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to accept invitation"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// deleteInvitation lets the invited user decline an invitation and ledger
+// owners revoke it.
+func (h *ledgerHandler) deleteInvitation(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type DeleteInvitationInput struct {
+		InvitationId int64 `json:"id" binding:"required"`
+	}
+
+	var input DeleteInvitationInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	invitation, err := h.ledgerRepository.GetInvitationById(input.InvitationId)
+	if err != nil || (invitation.UserId != userId && !h.ledgers.hasRole(invitation.LedgerId, userId, model.LedgerRoleOwner)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	if err := h.ledgerRepository.DeleteInvitation(invitation.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/currency"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/pkg/schedule"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxUpcomingDays = 366
+
+// How far back a new template may start, all its past occurrences get posted.
+const maxBackdateYears = 1
+
+type recurringTransactionHandler struct {
+	transactor                     repository.Transactor
+	recurringTransactionRepository repository.RecurringTransactionRepository
+	transactionCategoryRepository  repository.TransactionCategoryRepository
+	accountRepository              repository.AccountRepository
+	userRepository                 repository.UserRepository
+	ledgers                        ledgerAccess
+}
+
+func RegisterRecurringTransactionRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, recurringTransactionRepository repository.RecurringTransactionRepository, transactionCategoryRepository repository.TransactionCategoryRepository, accountRepository repository.AccountRepository, userRepository repository.UserRepository, ledgerRepository repository.LedgerRepository) {
+	handler := recurringTransactionHandler{
+		transactor:                     transactor,
+		recurringTransactionRepository: recurringTransactionRepository,
+		transactionCategoryRepository:  transactionCategoryRepository,
+		accountRepository:              accountRepository,
+		userRepository:                 userRepository,
+		ledgers:                        ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+	}
+
+	recurringRouterGroup := router.Group("/recurring").Use(auth.GetAuthMiddleware(jwtService))
+
+	recurringRouterGroup.POST("", handler.create)
+	recurringRouterGroup.GET("", handler.get)
+	recurringRouterGroup.PUT("", handler.update)
+	recurringRouterGroup.DELETE("", handler.deleteRecurringTransaction)
+
+	recurringRouterGroup.GET("/upcoming", handler.getUpcoming)
+	recurringRouterGroup.POST("/skip", handler.skip)
+}
+
+func (h *recurringTransactionHandler) create(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var recurring model.RecurringTransaction
+	if err := c.BindJSON(&recurring); err != nil || recurring.Price <= 0 || recurring.CategoryId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recurring transaction object"})
+		return
+	}
+
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+	rule, err := schedule.NewRule(recurring.Frequency, recurring.Interval, recurring.StartDate, recurring.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if rule.Start.Before(schedule.Today(time.Now()).AddDate(-maxBackdateYears, 0, 0)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start date must not be more than a year in the past"})
+		return
+	}
+
+	if recurring.Kind == "" {
+		recurring.Kind = model.TransactionKindExpense
+	}
+	if recurring.Kind != model.TransactionKindExpense && recurring.Kind != model.TransactionKindIncome {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid kind, expected expense or income"})
+		return
+	}
+
+	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(recurring.CategoryId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+	if category.ArchivedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category is archived"})
+		return
+	}
+
+	if recurring.Currency != "" {
+		recurring.Currency, err = currency.Normalize(recurring.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if recurring.AccountId != nil {
+		account, err := h.accountRepository.GetAccountById(*recurring.AccountId)
+		if err != nil || account.UserId != userId {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return
+		}
+		if recurring.Currency == "" {
+			recurring.Currency = account.Currency
+		}
+		if recurring.Currency != account.Currency {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "currency must match account currency " + account.Currency})
+			return
+		}
+	}
+
+	if recurring.Currency == "" {
+		user, err := h.userRepository.FindById(userId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		recurring.Currency = user.BaseCurrency
+	}
+
+	recurring.UserId = userId
+	id, err := h.recurringTransactionRepository.CreateRecurringTransaction(recurring)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "can't create recurring transaction",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "ok",
+		"id":     id,
+	})
+}
+
+func (h *recurringTransactionHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	recurringTransactions, err := h.recurringTransactionRepository.GetRecurringTransactions(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch recurring transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurringTransactions)
+}
+
+func (h *recurringTransactionHandler) update(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateRecurringTransactionInput struct {
+		RecurringTransactionId int64   `json:"id" binding:"required"`
+		CategoryId             int64   `json:"categoryId"`
+		Price                  int64   `json:"price"`
+		Description            *string `json:"description"`
+		Merchant               *string `json:"merchant"`
+		// An empty end date removes it.
+		EndDate *string `json:"endDate"`
+	}
+
+	var input UpdateRecurringTransactionInput
+	if err := c.BindJSON(&input); err != nil || input.RecurringTransactionId == 0 || input.Price < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	recurring, err := h.recurringTransactionRepository.GetRecurringTransactionById(input.RecurringTransactionId)
+	if err != nil || recurring.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	if input.CategoryId != 0 {
+		category, err := h.transactionCategoryRepository.GetTransactionCategoryById(input.CategoryId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleEditor) {
+			return
+		}
+		if category.ArchivedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category is archived"})
+			return
+		}
+		recurring.CategoryId = input.CategoryId
+	}
+	if input.Price != 0 {
+		recurring.Price = input.Price
+	}
+	if input.Description != nil {
+		recurring.Description = *input.Description
+	}
+	if input.Merchant != nil {
+		recurring.Merchant = *input.Merchant
+	}
+	if input.EndDate != nil {
+		recurring.EndDate = input.EndDate
+		if *input.EndDate == "" {
+			recurring.EndDate = nil
+		}
+		if _, err := schedule.NewRule(recurring.Frequency, recurring.Interval, recurring.StartDate, recurring.EndDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err = h.recurringTransactionRepository.UpdateRecurringTransaction(recurring)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *recurringTransactionHandler) deleteRecurringTransaction(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type DeleteRecurringTransactionInput struct {
+		RecurringTransactionId int64 `json:"id" binding:"required"`
+	}
+
+	var input DeleteRecurringTransactionInput
+	if err := c.BindJSON(&input); err != nil || input.RecurringTransactionId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	recurring, err := h.recurringTransactionRepository.GetRecurringTransactionById(input.RecurringTransactionId)
+	if err != nil || recurring.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		return h.recurringTransactionRepository.WithTx(tx).DeleteRecurringTransaction(recurring.Id)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// getUpcoming lists occurrences of all user's recurring transactions from
+// today for the given number of days, including the skipped ones.
+func (h *recurringTransactionHandler) getUpcoming(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	days := 30
+	if daysParam := c.Query("days"); daysParam != "" {
+		var err error
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days <= 0 || days > maxUpcomingDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid days parameter"})
+			return
+		}
+	}
+
+	recurringTransactions, err := h.recurringTransactionRepository.GetRecurringTransactions(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch recurring transactions"})
+		return
+	}
+
+	from := schedule.Today(time.Now())
+	to := from.AddDate(0, 0, days)
+
+	upcoming := []model.UpcomingOccurrence{}
+	for _, recurring := range recurringTransactions {
+		rule, err := schedule.NewRule(recurring.Frequency, recurring.Interval, recurring.StartDate, recurring.EndDate)
+		if err != nil {
+			continue
+		}
+
+		handled, err := h.recurringTransactionRepository.GetOccurrences(recurring.Id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch occurrences"})
+			return
+		}
+
+		for _, date := range rule.Between(from, to, maxUpcomingDays) {
+			day := date.Format(schedule.DateLayout)
+			status := handled[day]
+			if status == model.OccurrenceStatusPosted {
+				continue
+			}
+
+			upcoming = append(upcoming, model.UpcomingOccurrence{
+				RecurringTransactionId: recurring.Id,
+				Date:                   day,
+				Kind:                   recurring.Kind,
+				Price:                  recurring.Price,
+				Currency:               recurring.Currency,
+				CategoryId:             recurring.CategoryId,
+				Description:            recurring.Description,
+				Skipped:                status == model.OccurrenceStatusSkipped,
+			})
+		}
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].Date < upcoming[j].Date
+	})
+
+	c.JSON(http.StatusOK, upcoming)
+}
+
+func (h *recurringTransactionHandler) skip(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type SkipOccurrenceInput struct {
+		RecurringTransactionId int64  `json:"id" binding:"required"`
+		Date                   string `json:"date" binding:"required"`
+	}
+
+	var input SkipOccurrenceInput
+	if err := c.BindJSON(&input); err != nil || input.RecurringTransactionId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	date, err := schedule.ParseDate(input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date, expected YYYY-MM-DD"})
+		return
+	}
+
+	recurring, err := h.recurringTransactionRepository.GetRecurringTransactionById(input.RecurringTransactionId)
+	if err != nil || recurring.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	rule, err := schedule.NewRule(recurring.Frequency, recurring.Interval, recurring.StartDate, recurring.EndDate)
+	if err != nil || !rule.Has(date) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "there is no occurrence on this date"})
+		return
+	}
+
+	added, err := h.recurringTransactionRepository.AddOccurrence(recurring.Id, input.Date, model.OccurrenceStatusSkipped, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to skip occurrence"})
+		return
+	}
+	if !added {
+		c.JSON(http.StatusConflict, gin.H{"error": "occurrence is already posted or skipped"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type tagHandler struct {
+	transactor    repository.Transactor
+	tagRepository repository.TagRepository
+	ledgers       ledgerAccess
+}
+
+func RegisterTagRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, tagRepository repository.TagRepository, ledgerRepository repository.LedgerRepository, userRepository repository.UserRepository) {
+	handler := tagHandler{
+		transactor:    transactor,
+		tagRepository: tagRepository,
+		ledgers:       ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+	}
+
+	tagRouterGroup := router.Group("/transaction/tag").Use(auth.GetAuthMiddleware(jwtService))
+
+	tagRouterGroup.POST("", handler.create)
+	tagRouterGroup.GET("", handler.get)
+	tagRouterGroup.PUT("", handler.update)
+	tagRouterGroup.DELETE("", handler.deleteTag)
+}
+
+func (h *tagHandler) create(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var tag model.Tag
+	if err := c.BindJSON(&tag); err != nil || strings.TrimSpace(tag.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag object"})
+		return
+	}
+	tag.Name = strings.TrimSpace(tag.Name)
+
+	ledgerId := ""
+	if tag.LedgerId != 0 {
+		ledgerId = strconv.FormatInt(tag.LedgerId, 10)
+	}
+	tag.LedgerId, ok = h.ledgers.resolve(c, ledgerId, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+
+	id, err := h.tagRepository.CreateTag(tag)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "can't create tag, the name may be taken",
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "ok",
+		"id":     id,
+	})
+}
+
+func (h *tagHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	tags, err := h.tagRepository.GetTags(ledgerId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *tagHandler) update(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateTagInput struct {
+		TagId int64  `json:"id" binding:"required"`
+		Name  string `json:"name" binding:"required"`
+	}
+
+	var input UpdateTagInput
+	if err := c.BindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	tag, err := h.tagRepository.GetTagById(input.TagId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, tag.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	if err := h.tagRepository.UpdateTag(tag.Id, strings.TrimSpace(input.Name)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "can't update tag, the name may be taken"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *tagHandler) deleteTag(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type DeleteTagInput struct {
+		TagId int64 `json:"id" binding:"required"`
+	}
+
+	var input DeleteTagInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	tag, err := h.tagRepository.GetTagById(input.TagId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, tag.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		return h.tagRepository.WithTx(tx).DeleteTag(tag.Id)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	bulkOpCreate = "create"
+	bulkOpUpdate = "update"
+	bulkOpDelete = "delete"
+
+	bulkActionRecategorize = "recategorize"
+	bulkActionDelete       = "delete"
+
+	maxBulkItems = 1000
+)
+
+// bulkOperation is a create, update or delete of a single transaction. The
+// fields are those of POST and PUT /transaction, except for splits.
+type bulkOperation struct {
+	Op string `json:"op"`
+	updateTransactionInput
+}
+
+// bulkSelection applies the same action to all transactions of a ledger
+// matching Filter, which takes the parameters of GET /transaction.
+type bulkSelection struct {
+	LedgerId   int64             `json:"ledgerId"`
+	Filter     map[string]string `json:"filter"`
+	Action     string            `json:"action"`
+	CategoryId int64             `json:"categoryId"`
+}
+
+type bulkItemResult struct {
+	// Index is the position in operations, it is left out for transactions
+	// found by the selection.
+	Index  *int   `json:"index,omitempty"`
+	Op     string `json:"op"`
+	Id     int64  `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type bulkResult struct {
+	Applied bool             `json:"applied"`
+	Results []bulkItemResult `json:"results"`
+}
+
+// bulkStep is a validated operation ready to be applied.
+type bulkStep struct {
+	op          string
+	transaction model.Transaction
+	tagIds      *[]int64
+}
+
+// bulk runs a list of operations and a selection in one SQL transaction.
+// Everything is validated first, so either all of it is applied or, when any
+// item fails, nothing is and the results tell which items failed.
+func (h *transactionHandler) bulk(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type BulkInput struct {
+		Operations []bulkOperation `json:"operations"`
+		Selection  *bulkSelection  `json:"selection"`
+	}
+
+	var input BulkInput
+	if err := c.BindJSON(&input); err != nil || (len(input.Operations) == 0 && input.Selection == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide operations or a selection"})
+		return
+	}
+	if len(input.Operations) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many operations, the limit is " + strconv.Itoa(maxBulkItems)})
+		return
+	}
+
+	var selectedIds []int64
+	if input.Selection != nil {
+		selectedIds, ok = h.selectBulkTransactions(c, userId, *input.Selection)
+		if !ok {
+			return
+		}
+		if len(input.Operations)+len(selectedIds) > maxBulkItems {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the selection matches too many transactions, the limit is " + strconv.Itoa(maxBulkItems)})
+			return
+		}
+	}
+
+	ids := append([]int64{}, selectedIds...)
+	for _, operation := range input.Operations {
+		if operation.Op == bulkOpUpdate || operation.Op == bulkOpDelete {
+			ids = append(ids, operation.TransactionId)
+		}
+	}
+
+	transactions, err := h.transactionRepository.GetTransactionsAccess(ids, userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
+		return
+	}
+	splits, err := h.transactionRepository.GetSplits(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch splits"})
+		return
+	}
+
+	operations := input.Operations
+	results := make([]bulkItemResult, 0, len(operations)+len(selectedIds))
+	for i := range operations {
+		index := i
+		results = append(results, bulkItemResult{Index: &index, Op: operations[i].Op, Id: operations[i].TransactionId})
+	}
+	for _, id := range selectedIds {
+		operation := bulkOperation{Op: bulkOpDelete}
+		if input.Selection.Action == bulkActionRecategorize {
+			// Transfers and settlements have no category to change.
+			transaction := transactions[id].Transaction
+			if transaction.TransferId != nil || transaction.Kind == model.TransactionKindSettlement {
+				continue
+			}
+			operation.Op = bulkOpUpdate
+			operation.CategoryId = &input.Selection.CategoryId
+		}
+		operation.TransactionId = id
+		operations = append(operations, operation)
+		results = append(results, bulkItemResult{Op: operation.Op, Id: id})
+	}
+
+	steps := make([]bulkStep, len(operations))
+	seen := map[int64]bool{}
+	failed := false
+	for i, operation := range operations {
+		if operation.Op != bulkOpCreate {
+			if seen[operation.TransactionId] {
+				results[i].Status, results[i].Error = "error", "transaction appears more than once"
+				failed = true
+				continue
+			}
+			seen[operation.TransactionId] = true
+		}
+
+		step, status, message := h.prepareBulkStep(operation, transactions, splits, userId)
+		if status != 0 {
+			results[i].Status, results[i].Error = "error", message
+			failed = true
+			continue
+		}
+		steps[i] = step
+	}
+
+	if failed {
+		for i := range results {
+			if results[i].Status == "" {
+				results[i].Status = "skipped"
+			}
+		}
+		c.JSON(http.StatusBadRequest, bulkResult{Applied: false, Results: results})
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		transactionRepository := h.transactionRepository.WithTx(tx)
+		tagRepository := h.tagRepository.WithTx(tx)
+
+		for i, step := range steps {
+			switch step.op {
+			case bulkOpCreate:
+				id, err := transactionRepository.CreateTransaction(step.transaction)
+				if err != nil {
+					return err
+				}
+				results[i].Id = id
+				step.transaction.Id = id
+			case bulkOpUpdate:
+				if err := transactionRepository.UpdateTransaction(step.transaction, userId); err != nil {
+					return err
+				}
+			case bulkOpDelete:
+				if err := transactionRepository.DeleteTransaction(step.transaction.Id, userId); err != nil {
+					return err
+				}
+			}
+
+			if step.tagIds != nil {
+				if err := tagRepository.SetTransactionTags(step.transaction.Id, *step.tagIds); err != nil {
+					return err
+				}
+			}
+			results[i].Status = "ok"
+		}
+
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply operations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bulkResult{Applied: true, Results: results})
+}
+
+// selectBulkTransactions returns the ids of the transactions the selection
+// applies to, after checking the user may edit its ledger.
+func (h *transactionHandler) selectBulkTransactions(c *gin.Context, userId int64, selection bulkSelection) ([]int64, bool) {
+	if selection.Action != bulkActionRecategorize && selection.Action != bulkActionDelete {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid selection action, expected recategorize or delete"})
+		return nil, false
+	}
+	if selection.Action == bulkActionRecategorize && selection.CategoryId == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide categoryId to recategorize to"})
+		return nil, false
+	}
+
+	filter, err := parseTransactionFilter(func(key string) string { return selection.Filter[key] })
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	ledgerId := ""
+	if selection.LedgerId != 0 {
+		ledgerId = strconv.FormatInt(selection.LedgerId, 10)
+	}
+	resolvedLedgerId, ok := h.ledgers.resolve(c, ledgerId, userId, model.LedgerRoleEditor)
+	if !ok {
+		return nil, false
+	}
+
+	ids, err := h.transactionRepository.GetTransactionIds(resolvedLedgerId, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
+		return nil, false
+	}
+
+	return ids, true
+}
+
+// prepareBulkStep validates one operation the same way the single transaction
+// endpoints do. It returns the status and message of the failure, if any.
+func (h *transactionHandler) prepareBulkStep(operation bulkOperation, transactions map[int64]repository.TransactionAccess, splits map[int64][]model.TransactionSplit, userId int64) (bulkStep, int, string) {
+	step := bulkStep{op: operation.Op, tagIds: operation.TagIds}
+	if operation.Split != nil {
+		return step, http.StatusBadRequest, "splits can't be changed in bulk, use PUT /transaction"
+	}
+
+	if operation.Op == bulkOpCreate {
+		if operation.Price == 0 || operation.CategoryId == nil || *operation.CategoryId == 0 {
+			return step, http.StatusBadRequest, "invalid transaction object"
+		}
+
+		step.transaction = model.Transaction{
+			Price:      operation.Price,
+			Kind:       operation.Kind,
+			Currency:   operation.Currency,
+			CategoryId: *operation.CategoryId,
+			AccountId:  operation.AccountId,
+			Date:       operation.Date,
+		}
+		if operation.Description != nil {
+			step.transaction.Description = *operation.Description
+		}
+		if operation.Merchant != nil {
+			step.transaction.Merchant = *operation.Merchant
+		}
+		if operation.Notes != nil {
+			step.transaction.Notes = *operation.Notes
+		}
+
+		var tagIds []int64
+		if operation.TagIds != nil {
+			tagIds = *operation.TagIds
+		}
+		status, message := h.prepareTransaction(&step.transaction, tagIds, userId)
+		return step, status, message
+	}
+
+	if operation.Op != bulkOpUpdate && operation.Op != bulkOpDelete {
+		return step, http.StatusBadRequest, "invalid op, expected create, update or delete"
+	}
+
+	access, ok := transactions[operation.TransactionId]
+	if !ok {
+		return step, http.StatusNotFound, "not found"
+	}
+	if status, message := ledgerRoleStatus(access.Role, model.LedgerRoleEditor); status != 0 {
+		return step, status, message
+	}
+	step.transaction = access.Transaction
+
+	if operation.Op == bulkOpDelete {
+		step.tagIds = nil
+		return step, 0, ""
+	}
+
+	price, kind := step.transaction.Price, step.transaction.Kind
+	if status, message := h.applyUpdate(&step.transaction, operation.updateTransactionInput, userId); status != 0 {
+		return step, status, message
+	}
+	if len(splits[step.transaction.Id]) > 0 && (step.transaction.Price != price || step.transaction.Kind != kind) {
+		return step, http.StatusBadRequest, "price or kind of a split transaction can't be changed in bulk, use PUT /transaction"
+	}
+
+	return step, 0, ""
+}
@@ -1,29 +1,41 @@
 package handler
 
 import (
+	"database/sql"
 	"expenses_tracker/internal/model"
 	"expenses_tracker/internal/pkg/auth"
 	"expenses_tracker/internal/pkg/jwt"
 	"expenses_tracker/internal/repository"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type transactionCategoryHandler struct {
+	transactor                    repository.Transactor
 	transactionCategoryRepository repository.TransactionCategoryRepository
+	ledgers                       ledgerAccess
 }
 
-func RegisterTransactionCategoryRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactionCategoryRepository repository.TransactionCategoryRepository) {
+func RegisterTransactionCategoryRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, transactionCategoryRepository repository.TransactionCategoryRepository, ledgerRepository repository.LedgerRepository, userRepository repository.UserRepository) {
 	handler := transactionCategoryHandler{
+		transactor:                    transactor,
 		transactionCategoryRepository: transactionCategoryRepository,
+		ledgers:                       ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
 	}
 
 	transactionCategoryRouterGroup := router.Group("/transaction/category").Use(auth.GetAuthMiddleware(jwtService))
 
 	transactionCategoryRouterGroup.POST("", handler.create)
 	transactionCategoryRouterGroup.GET("", handler.get)
+	transactionCategoryRouterGroup.PUT("", handler.update)
 	transactionCategoryRouterGroup.DELETE("", handler.deleteCategory)
+	transactionCategoryRouterGroup.PUT("/order", handler.reorder)
+	transactionCategoryRouterGroup.PUT("/parent", handler.setParent)
+	transactionCategoryRouterGroup.PUT("/archive", handler.setArchived)
+	transactionCategoryRouterGroup.GET("/template", handler.getTemplates)
+	transactionCategoryRouterGroup.POST("/template", handler.applyTemplate)
 }
 
 func (h *transactionCategoryHandler) create(c *gin.Context) {
@@ -38,9 +50,51 @@ func (h *transactionCategoryHandler) create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category object"})
 		return
 	}
+	if !model.IsValidCategoryColor(category.Color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid color, expected a hex code like #ffaa00"})
+		return
+	}
+	if !model.IsValidCategoryIcon(category.Icon) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid icon, expected lowercase letters, digits, dashes and underscores"})
+		return
+	}
+
+	if category.ParentId != nil && *category.ParentId == 0 {
+		category.ParentId = nil
+	}
+
+	// A child goes to the ledger of its parent unless another one is named,
+	// which the parent check below then rejects. A parent in a ledger the user
+	// can't edit is not found either, so ids of other ledgers can't be probed.
+	var parent model.TransactionCategory
+	if category.ParentId != nil {
+		var err error
+		parent, err = h.transactionCategoryRepository.GetTransactionCategoryById(*category.ParentId)
+		if err != nil || !h.ledgers.hasRole(parent.LedgerId, userId, model.LedgerRoleEditor) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "parent category not found"})
+			return
+		}
+		if category.LedgerId == 0 {
+			category.LedgerId = parent.LedgerId
+		}
+	}
+
+	ledgerId := ""
+	if category.LedgerId != 0 {
+		ledgerId = strconv.FormatInt(category.LedgerId, 10)
+	}
+	category.LedgerId, ok = h.ledgers.resolve(c, ledgerId, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+
+	if category.ParentId != nil && parent.LedgerId != category.LedgerId {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parent category must be in the same ledger"})
+		return
+	}
 
 	category.UserId = userId
-	err := h.transactionCategoryRepository.CreateTransactionCategory(category)
+	_, err := h.transactionCategoryRepository.CreateTransactionCategory(category)
 
 	if err != nil {
 		c.JSON(400, gin.H{
@@ -61,15 +115,137 @@ func (h *transactionCategoryHandler) get(c *gin.Context) {
 		return
 	}
 
-	categories, err := h.transactionCategoryRepository.GetTransactionCategories(userId)
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	includeArchived, _ := strconv.ParseBool(c.Query("archived"))
+
+	categories, err := h.transactionCategoryRepository.GetTransactionCategories(ledgerId, includeArchived)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch categories"})
 		return
 	}
 
+	if tree, _ := strconv.ParseBool(c.Query("tree")); tree {
+		c.JSON(http.StatusOK, buildCategoryTree(categories))
+		return
+	}
+
 	c.JSON(http.StatusOK, categories)
 }
 
+func (h *transactionCategoryHandler) update(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateCategoryInput struct {
+		CategoryId int64   `json:"id" binding:"required"`
+		Name       *string `json:"name"`
+		Color      *string `json:"color"`
+		Icon       *string `json:"icon"`
+	}
+
+	var input UpdateCategoryInput
+	if err := c.BindJSON(&input); err != nil || (input.Name != nil && *input.Name == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+	if input.Color != nil && !model.IsValidCategoryColor(*input.Color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid color, expected a hex code like #ffaa00"})
+		return
+	}
+	if input.Icon != nil && !model.IsValidCategoryIcon(*input.Icon) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid icon, expected lowercase letters, digits, dashes and underscores"})
+		return
+	}
+
+	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(input.CategoryId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	if input.Name != nil {
+		category.Name = *input.Name
+	}
+	if input.Color != nil {
+		category.Color = *input.Color
+	}
+	if input.Icon != nil {
+		category.Icon = *input.Icon
+	}
+
+	err = h.transactionCategoryRepository.UpdateCategoryById(category.Id, category.Name, category.Color, category.Icon)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// reorder sets the sort order of categories of a ledger to their position in
+// ids. Categories left out keep their order.
+func (h *transactionCategoryHandler) reorder(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type ReorderInput struct {
+		LedgerId    int64   `json:"ledgerId"`
+		CategoryIds []int64 `json:"ids" binding:"required"`
+	}
+
+	var input ReorderInput
+	if err := c.BindJSON(&input); err != nil || len(input.CategoryIds) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	ledgerId := ""
+	if input.LedgerId != 0 {
+		ledgerId = strconv.FormatInt(input.LedgerId, 10)
+	}
+	input.LedgerId, ok = h.ledgers.resolve(c, ledgerId, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+
+	for _, categoryId := range input.CategoryIds {
+		category, err := h.transactionCategoryRepository.GetTransactionCategoryById(categoryId)
+		if err != nil || category.LedgerId != input.LedgerId {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category " + strconv.FormatInt(categoryId, 10) + " not found in the ledger"})
+			return
+		}
+	}
+
+	err := h.transactor.InTransaction(func(tx *sql.Tx) error {
+		transactionCategoryRepository := h.transactionCategoryRepository.WithTx(tx)
+		for i, categoryId := range input.CategoryIds {
+			if err := transactionCategoryRepository.SetSortOrder(categoryId, int64(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
 func (h *transactionCategoryHandler) deleteCategory(c *gin.Context) {
 	userId, ok := auth.GetUserId(c)
 	if !ok {
@@ -78,7 +254,9 @@ func (h *transactionCategoryHandler) deleteCategory(c *gin.Context) {
 	}
 
 	type DeleteCategoryInput struct {
-		CategoryId int64 `json:"id" binding:"required"`
+		CategoryId       int64  `json:"id" binding:"required"`
+		Mode             string `json:"mode"`
+		TargetCategoryId int64  `json:"targetCategoryId"`
 	}
 
 	var input DeleteCategoryInput
@@ -87,13 +265,65 @@ func (h *transactionCategoryHandler) deleteCategory(c *gin.Context) {
 		return
 	}
 
+	if input.Mode == "" {
+		input.Mode = model.CategoryDeleteModeRefuse
+	}
+	if input.Mode != model.CategoryDeleteModeRefuse && input.Mode != model.CategoryDeleteModeReassign && input.Mode != model.CategoryDeleteModeCascade {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mode, expected refuse, reassign or cascade"})
+		return
+	}
+
 	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(input.CategoryId)
-	if err != nil || category.UserId != userId {
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
+	if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	if input.Mode == model.CategoryDeleteModeReassign {
+		target, err := h.transactionCategoryRepository.GetTransactionCategoryById(input.TargetCategoryId)
+		if err != nil || target.LedgerId != category.LedgerId || target.Id == category.Id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target category not found"})
+			return
+		}
+		if target.ArchivedAt != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target category is archived"})
+			return
+		}
+	}
+
+	if input.Mode == model.CategoryDeleteModeRefuse {
+		used, err := h.transactionCategoryRepository.IsCategoryUsed(category.Id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
+			return
+		}
+		if used {
+			c.JSON(http.StatusConflict, gin.H{"error": "category has transactions, reassign or cascade them, or archive the category"})
+			return
+		}
+	}
 
-	err = h.transactionCategoryRepository.DeleteTransactionCategory(category.Id)
+	// The category goes to the trash with its budgets in every mode, its
+	// children move up to its parent.
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		transactionCategoryRepository := h.transactionCategoryRepository.WithTx(tx)
+
+		if input.Mode == model.CategoryDeleteModeReassign {
+			if err := transactionCategoryRepository.ReassignCategory(category.Id, input.TargetCategoryId, userId); err != nil {
+				return err
+			}
+		}
+		if err := transactionCategoryRepository.DeleteTransactionCategory(category.Id); err != nil {
+			return err
+		}
+		if input.Mode == model.CategoryDeleteModeCascade {
+			return transactionCategoryRepository.DeleteCategoryTransactions(category.Id, userId)
+		}
+		return nil
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
 		return
@@ -101,3 +331,140 @@ func (h *transactionCategoryHandler) deleteCategory(c *gin.Context) {
 
 	c.String(http.StatusOK, "OK")
 }
+
+// setArchived hides a category from the list and from new transactions while
+// its existing transactions stay where they are.
+func (h *transactionCategoryHandler) setArchived(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type SetArchivedInput struct {
+		CategoryId int64 `json:"id" binding:"required"`
+		Archived   bool  `json:"archived"`
+	}
+
+	var input SetArchivedInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(input.CategoryId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	if err := h.transactionCategoryRepository.SetArchived(category.Id, input.Archived); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// setParent moves a category under another one of the same ledger, or to the
+// top level when parentId is empty. A category can't be moved below itself.
+func (h *transactionCategoryHandler) setParent(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type SetParentInput struct {
+		CategoryId int64  `json:"id" binding:"required"`
+		ParentId   *int64 `json:"parentId"`
+	}
+
+	var input SetParentInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+	if input.ParentId != nil && *input.ParentId == 0 {
+		input.ParentId = nil
+	}
+
+	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(input.CategoryId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	if input.ParentId != nil {
+		if status, message := h.checkParent(category, *input.ParentId); status != 0 {
+			c.JSON(status, gin.H{"error": message})
+			return
+		}
+	}
+
+	if err := h.transactionCategoryRepository.SetParent(category.Id, input.ParentId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// checkParent makes sure parentId can become the parent of category without
+// leaving its ledger or making a cycle. It returns the status and message of
+// the response to send on failure.
+func (h *transactionCategoryHandler) checkParent(category model.TransactionCategory, parentId int64) (int, string) {
+	parent, err := h.transactionCategoryRepository.GetTransactionCategoryById(parentId)
+	if err != nil || parent.LedgerId != category.LedgerId {
+		return http.StatusNotFound, "parent category not found"
+	}
+
+	cycle, err := h.transactionCategoryRepository.IsDescendant(parent.Id, category.Id)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to check parent"
+	}
+	if cycle {
+		return http.StatusBadRequest, "a category can't be moved below itself"
+	}
+
+	return 0, ""
+}
+
+// buildCategoryTree nests categories under their parents. Categories whose
+// parent is missing from the list are returned at the top level.
+func buildCategoryTree(categories []model.TransactionCategory) []model.TransactionCategory {
+	children := map[int64][]model.TransactionCategory{}
+	known := map[int64]bool{}
+	for _, category := range categories {
+		known[category.Id] = true
+	}
+
+	roots := []model.TransactionCategory{}
+	for _, category := range categories {
+		if category.ParentId != nil && known[*category.ParentId] {
+			children[*category.ParentId] = append(children[*category.ParentId], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	var attach func(category model.TransactionCategory) model.TransactionCategory
+	attach = func(category model.TransactionCategory) model.TransactionCategory {
+		for _, child := range children[category.Id] {
+			category.Children = append(category.Children, attach(child))
+		}
+		return category
+	}
+
+	for i, root := range roots {
+		roots[i] = attach(root)
+	}
+
+	return roots
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/categorytemplate"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type categoryTemplateSet struct {
+	Name       string             `json:"name"`
+	Categories []categoryTemplate `json:"categories"`
+}
+
+type categoryTemplate struct {
+	Name     string             `json:"name"`
+	Color    string             `json:"color"`
+	Icon     string             `json:"icon"`
+	Children []categoryTemplate `json:"children,omitempty"`
+}
+
+func (h *transactionCategoryHandler) getTemplates(c *gin.Context) {
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+	}
+	locale = categorytemplate.Locale(locale)
+
+	sets := []categoryTemplateSet{}
+	for _, name := range categorytemplate.Sets() {
+		templates, _ := categorytemplate.Get(name)
+		sets = append(sets, categoryTemplateSet{Name: name, Categories: localizeTemplates(templates, locale)})
+	}
+
+	c.JSON(http.StatusOK, sets)
+}
+
+// applyTemplate adds the categories of a template set to a ledger. Categories
+// whose name already exists under the same parent are reused, so applying a
+// set twice changes nothing.
+func (h *transactionCategoryHandler) applyTemplate(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type ApplyTemplateInput struct {
+		Set      string `json:"set"`
+		Locale   string `json:"locale"`
+		LedgerId int64  `json:"ledgerId"`
+	}
+
+	var input ApplyTemplateInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	if input.Set == "" {
+		input.Set = categorytemplate.DefaultSet
+	}
+	templates, found := categorytemplate.Get(input.Set)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown template set, expected one of " + strings.Join(categorytemplate.Sets(), ", ")})
+		return
+	}
+
+	if input.Locale == "" {
+		input.Locale = c.GetHeader("Accept-Language")
+	}
+
+	ledgerId := ""
+	if input.LedgerId != 0 {
+		ledgerId = strconv.FormatInt(input.LedgerId, 10)
+	}
+	input.LedgerId, ok = h.ledgers.resolve(c, ledgerId, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+
+	var created int
+	err := h.transactor.InTransaction(func(tx *sql.Tx) error {
+		var err error
+		created, err = applyCategoryTemplates(h.transactionCategoryRepository.WithTx(tx), userId, input.LedgerId, templates, categorytemplate.Locale(input.Locale))
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"created": created,
+	})
+}
+
+// applyCategoryTemplates creates the templates in the ledger, skipping the
+// ones whose name is already taken under the same parent, and returns how
+// many categories it created.
+func applyCategoryTemplates(transactionCategoryRepository repository.TransactionCategoryRepository, userId int64, ledgerId int64, templates []categorytemplate.Category, locale string) (int, error) {
+	existing, err := transactionCategoryRepository.GetTransactionCategories(ledgerId, true)
+	if err != nil {
+		return 0, err
+	}
+
+	categoryKey := func(parentId *int64, name string) string {
+		var parent int64
+		if parentId != nil {
+			parent = *parentId
+		}
+		return strconv.FormatInt(parent, 10) + "/" + strings.ToLower(name)
+	}
+
+	ids := map[string]int64{}
+	for _, category := range existing {
+		ids[categoryKey(category.ParentId, category.Name)] = category.Id
+	}
+
+	created := 0
+	var apply func(parentId *int64, templates []categorytemplate.Category) error
+	apply = func(parentId *int64, templates []categorytemplate.Category) error {
+		for _, template := range templates {
+			name := template.Name(locale)
+			id, found := ids[categoryKey(parentId, name)]
+			if !found {
+				id, err = transactionCategoryRepository.CreateTransactionCategory(model.TransactionCategory{
+					UserId:   userId,
+					LedgerId: ledgerId,
+					ParentId: parentId,
+					Name:     name,
+					Color:    template.Color,
+					Icon:     template.Icon,
+				})
+				if err != nil {
+					return err
+				}
+				created++
+			}
+
+			if err := apply(&id, template.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return created, apply(nil, templates)
+}
+
+func localizeTemplates(templates []categorytemplate.Category, locale string) []categoryTemplate {
+	categories := []categoryTemplate{}
+	for _, template := range templates {
+		category := categoryTemplate{
+			Name:  template.Name(locale),
+			Color: template.Color,
+			Icon:  template.Icon,
+		}
+		if len(template.Children) > 0 {
+			category.Children = localizeTemplates(template.Children, locale)
+		}
+		categories = append(categories, category)
+	}
+	return categories
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/csv"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/xlsx"
+	"expenses_tracker/internal/repository"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var exportHeader = []string{"Id", "Date", "Kind", "Price", "Currency", "Category", "Color", "Description", "Merchant", "Notes"}
+
+func (h *transactionHandler) export(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format parameter, expected csv or xlsx"})
+		return
+	}
+
+	filter, err := parseTransactionFilter(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	filename := exportFilename(c.Query("from"), c.Query("to"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "xlsx" {
+		err = h.exportXlsx(c, ledgerId, filter)
+	} else {
+		err = h.exportCsv(c, ledgerId, filter)
+	}
+
+	if err != nil {
+		// Headers are most likely sent already, so the only thing left to do is
+		// to cut the response short.
+		log.Println("transaction export failed:", err)
+		c.Abort()
+	}
+}
+
+func (h *transactionHandler) exportCsv(c *gin.Context, ledgerId int64, filter repository.TransactionFilter) error {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+
+	err := h.transactionRepository.ExportTransactions(ledgerId, filter, func(transaction model.Transaction) error {
+		return writer.Write([]string{
+			strconv.FormatInt(transaction.Id, 10),
+			transaction.Date,
+			transaction.Kind,
+			strconv.FormatInt(transaction.Price, 10),
+			transaction.Currency,
+			csvText(transaction.Category.Name),
+			transaction.Category.Color,
+			csvText(transaction.Description),
+			csvText(transaction.Merchant),
+			csvText(transaction.Notes),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (h *transactionHandler) exportXlsx(c *gin.Context, ledgerId int64, filter repository.TransactionFilter) error {
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Status(http.StatusOK)
+
+	writer, err := xlsx.NewWriter(c.Writer, "Transactions")
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(exportHeader))
+	for i, column := range exportHeader {
+		header[i] = column
+	}
+	if err := writer.WriteRow(header); err != nil {
+		return err
+	}
+
+	err = h.transactionRepository.ExportTransactions(ledgerId, filter, func(transaction model.Transaction) error {
+		return writer.WriteRow([]interface{}{
+			transaction.Id,
+			transaction.Date,
+			transaction.Kind,
+			transaction.Price,
+			transaction.Currency,
+			transaction.Category.Name,
+			transaction.Category.Color,
+			transaction.Description,
+			transaction.Merchant,
+			transaction.Notes,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// csvText keeps spreadsheet applications from running a text cell as a
+// formula, the text comes from any member of the ledger.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func exportFilename(from string, to string, format string) string {
+	if from == "" && to == "" {
+		return fmt.Sprintf("transactions_%s.%s", time.Now().Format("2006-01-02"), format)
+	}
+
+	name := "transactions"
+	if from != "" {
+		name += "_from_" + sanitizeFilenamePart(from)
+	}
+	if to != "" {
+		name += "_to_" + sanitizeFilenamePart(to)
+	}
+
+	return name + "." + format
+}
+
+func sanitizeFilenamePart(value string) string {
+	result := make([]rune, 0, len(value))
+	for _, r := range value {
+		if (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '-' {
+			result = append(result, r)
+		} else {
+			result = append(result, '-')
+		}
+	}
+	return string(result)
+}
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
 	"expenses_tracker/internal/model"
 	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/currency"
 	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/pkg/utils"
 	"expenses_tracker/internal/repository"
 	"net/http"
 	"strconv"
@@ -13,14 +17,26 @@ import (
 )
 
 type transactionHandler struct {
+	transactor                    repository.Transactor
 	transactionRepository         repository.TransactionRepository
 	transactionCategoryRepository repository.TransactionCategoryRepository
+	userRepository                repository.UserRepository
+	accountRepository             repository.AccountRepository
+	tagRepository                 repository.TagRepository
+	ledgers                       ledgerAccess
+	importMaxSize                 int64
 }
 
-func RegisterTransactionRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactionRepository repository.TransactionRepository, transactionCategoryRepository repository.TransactionCategoryRepository) {
+func RegisterTransactionRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, transactionRepository repository.TransactionRepository, transactionCategoryRepository repository.TransactionCategoryRepository, userRepository repository.UserRepository, accountRepository repository.AccountRepository, ledgerRepository repository.LedgerRepository, tagRepository repository.TagRepository, importMaxSize int64) {
 	handler := transactionHandler{
+		transactor:                    transactor,
 		transactionRepository:         transactionRepository,
 		transactionCategoryRepository: transactionCategoryRepository,
+		userRepository:                userRepository,
+		accountRepository:             accountRepository,
+		tagRepository:                 tagRepository,
+		ledgers:                       ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+		importMaxSize:                 importMaxSize,
 	}
 
 	transactionRouterGroup := router.Group("/transaction").Use(auth.GetAuthMiddleware(jwtService))
@@ -29,8 +45,13 @@ func RegisterTransactionRoutes(router *gin.Engine, jwtService *jwt.JwtService, t
 	transactionRouterGroup.GET("", handler.get)
 	transactionRouterGroup.PUT("", handler.update)
 	transactionRouterGroup.DELETE("", handler.deleteTransaction)
+	transactionRouterGroup.POST("/bulk", handler.bulk)
 
 	transactionRouterGroup.GET("/total", handler.getTotalPrice)
+	transactionRouterGroup.GET("/stats", handler.getStats)
+	transactionRouterGroup.GET("/export", handler.export)
+	transactionRouterGroup.POST("/import", handler.importTransactions)
+	transactionRouterGroup.GET("/:id/history", handler.getHistory)
 }
 
 func (h *transactionHandler) create(c *gin.Context) {
@@ -40,20 +61,47 @@ func (h *transactionHandler) create(c *gin.Context) {
 		return
 	}
 
-	var transaction model.Transaction
-	if err := c.BindJSON(&transaction); err != nil || transaction.Price == 0 || transaction.CategoryId == 0 {
+	type CreateTransactionInput struct {
+		model.Transaction
+		Split  *splitInput `json:"split"`
+		TagIds []int64     `json:"tagIds"`
+	}
+
+	var input CreateTransactionInput
+	if err := c.BindJSON(&input); err != nil || input.Price == 0 || input.CategoryId == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction object"})
 		return
 	}
+	transaction := input.Transaction
 
-	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(transaction.CategoryId)
-	if err != nil || category.UserId != userId {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	if status, message := h.prepareTransaction(&transaction, input.TagIds, userId); status != 0 {
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
 
-	transaction.UserId = userId
-	err = h.transactionRepository.CreateTransaction(transaction)
+	var splits []model.TransactionSplit
+	if input.Split != nil {
+		if transaction.Kind != model.TransactionKindExpense {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only expenses can be split"})
+			return
+		}
+		if splits, ok = resolveSplits(c, h.ledgers, transaction.LedgerId, transaction.Price, *input.Split); !ok {
+			return
+		}
+	}
+
+	err := h.transactor.InTransaction(func(tx *sql.Tx) error {
+		transactionRepository := h.transactionRepository.WithTx(tx)
+
+		id, err := transactionRepository.CreateTransaction(transaction)
+		if err != nil {
+			return err
+		}
+		if err := transactionRepository.SetSplits(id, splits); err != nil {
+			return err
+		}
+		return h.tagRepository.WithTx(tx).SetTransactionTags(id, input.TagIds)
+	})
 
 	if err != nil {
 		c.JSON(400, gin.H{
@@ -74,49 +122,94 @@ func (h *transactionHandler) get(c *gin.Context) {
 		return
 	}
 
-	type GetTransactionsInput struct {
-		CategoryIds []int64               `json:"categoryIds" binding:"required"`
-		Pagination  repository.Pagination `json:"pagination" binding:"required"`
+	filter, err := parseTransactionFilter(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	categoryIdsParam := c.Query("categoryIds")
-	var categoryIds []int64
-	if categoryIdsParam != "" {
-		categoryIdsStr := strings.Split(categoryIdsParam, ",")
-		for _, idStr := range categoryIdsStr {
-			id, err := strconv.ParseInt(idStr, 10, 64)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid categoryId: " + idStr})
-				return
-			}
-			categoryIds = append(categoryIds, id)
-		}
+	items, err := strconv.ParseInt(c.Query("items"), 10, 64)
+	if err != nil || items <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing items parameter"})
+		return
 	}
 
-	page, err := strconv.ParseInt(c.Query("page"), 10, 64)
-	if err != nil || page <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing page parameter"})
+	order := repository.TransactionOrder{Field: c.DefaultQuery("sort", repository.TransactionSortDate)}
+	if !repository.IsValidTransactionSort(order.Field) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort parameter, expected date, price or createdAt"})
 		return
 	}
 
-	items, err := strconv.ParseInt(c.Query("items"), 10, 64)
-	if err != nil || items <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing items parameter"})
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+		order.Descending = true
+	case "asc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order parameter, expected asc or desc"})
 		return
 	}
 
-	pagination := repository.Pagination{
-		Page:  page,
-		Items: items,
+	// Without page the list is paged by cursor: the first page is requested
+	// without one, the next ones with the nextCursor of the previous page.
+	pagination := repository.Pagination{Page: 1, Items: items}
+	if pageParam := c.Query("page"); pageParam != "" {
+		if c.Query("cursor") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "page and cursor cannot be used together"})
+			return
+		}
+
+		pagination.Page, err = strconv.ParseInt(pageParam, 10, 64)
+		if err != nil || pagination.Page <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page parameter"})
+			return
+		}
+	} else if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := repository.DecodeTransactionCursor(cursorParam, order.Field, order.Descending)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		order.After = &cursor
 	}
 
 	resolvedPagination := repository.ResolvePagination(&pagination)
 
-	transactions, err := h.transactionRepository.GetTransactions(userId, categoryIds, resolvedPagination)
+	convertTo, err := h.getConvertTo(c, userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.ConvertTo = convertTo
+
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	transactions, err := h.transactionRepository.GetTransactions(ledgerId, filter, order, resolvedPagination)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
+		return
+	}
+
+	transactionIds := make([]int64, len(transactions.Items))
+	for i, transaction := range transactions.Items {
+		transactionIds[i] = transaction.Id
+	}
+	splits, err := h.transactionRepository.GetSplits(transactionIds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
+		return
+	}
+	tags, err := h.tagRepository.GetTransactionTags(transactionIds)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
 		return
 	}
+	for i := range transactions.Items {
+		transactions.Items[i].Splits = splits[transactions.Items[i].Id]
+		transactions.Items[i].Tags = tags[transactions.Items[i].Id]
+	}
 
 	c.JSON(http.StatusOK, transactions)
 }
@@ -128,26 +221,65 @@ func (h *transactionHandler) update(c *gin.Context) {
 		return
 	}
 
-	type UpdateTransactionInput struct {
-		TransactionId int64 `json:"id" binding:"required"`
-		Price         int64 `json:"price" binding:"required"`
-	}
-
-	var input UpdateTransactionInput
-	if err := c.BindJSON(&input); err != nil || input.TransactionId == 0 || input.Price == 0 {
+	var input updateTransactionInput
+	if err := c.BindJSON(&input); err != nil || input.TransactionId == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
 		return
 	}
 
 	transaction, err := h.transactionRepository.GetTransactionById(input.TransactionId)
-	if err != nil || transaction.UserId != userId {
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
+	if !h.ledgers.check(c, transaction.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	currentSplits, err := h.transactionRepository.GetSplits([]int64{transaction.Id})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch splits"})
+		return
+	}
+	splits := currentSplits[transaction.Id]
+	splitPrice, splitKind := transaction.Price, transaction.Kind
 
-	transaction.Price = input.Price
+	if status, message := h.applyUpdate(&transaction, input, userId); status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	// A split has to be given again when the price changes, an empty list of
+	// parts removes it.
+	if input.Split != nil && len(input.Split.Parts) == 0 {
+		splits = nil
+	} else if input.Split != nil {
+		if transaction.Kind != model.TransactionKindExpense {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only expenses can be split"})
+			return
+		}
+		if splits, ok = resolveSplits(c, h.ledgers, transaction.LedgerId, transaction.Price, *input.Split); !ok {
+			return
+		}
+	} else if len(splits) > 0 && (transaction.Price != splitPrice || transaction.Kind != splitKind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide the split again when changing the price or kind of a split transaction"})
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		transactionRepository := h.transactionRepository.WithTx(tx)
 
-	err = h.transactionRepository.UpdateTransaction(transaction)
+		if err := transactionRepository.UpdateTransaction(transaction, userId); err != nil {
+			return err
+		}
+		if err := transactionRepository.SetSplits(transaction.Id, splits); err != nil {
+			return err
+		}
+		if input.TagIds == nil {
+			return nil
+		}
+		return h.tagRepository.WithTx(tx).SetTransactionTags(transaction.Id, *input.TagIds)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
 		return
@@ -174,12 +306,17 @@ func (h *transactionHandler) deleteTransaction(c *gin.Context) {
 	}
 
 	transaction, err := h.transactionRepository.GetTransactionById(input.TransactionId)
-	if err != nil || transaction.UserId != userId {
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
+	if !h.ledgers.check(c, transaction.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
 
-	err = h.transactionRepository.DeleteTransaction(transaction.Id)
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		return h.transactionRepository.WithTx(tx).DeleteTransaction(transaction.Id, userId)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete"})
 		return
@@ -188,6 +325,55 @@ func (h *transactionHandler) deleteTransaction(c *gin.Context) {
 	c.String(http.StatusOK, "OK")
 }
 
+// getHistory lists every change of a transaction, also after it was moved to
+// the trash. Changes made in a ledger the user can't view are left out.
+func (h *transactionHandler) getHistory(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	transactionId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction id"})
+		return
+	}
+
+	transaction, err := h.transactionRepository.GetTransactionById(transactionId)
+	if err != nil {
+		transaction, err = h.transactionRepository.GetDeletedTransactionById(transactionId)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, transaction.LedgerId, userId, model.LedgerRoleViewer) {
+		return
+	}
+
+	history, err := h.transactionRepository.GetHistory(transaction.Id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch history"})
+		return
+	}
+
+	visible := map[int64]bool{transaction.LedgerId: true}
+	changes := []model.TransactionChange{}
+	for _, change := range history {
+		allowed, known := visible[change.LedgerId]
+		if !known {
+			allowed = h.ledgers.hasRole(change.LedgerId, userId, model.LedgerRoleViewer)
+			visible[change.LedgerId] = allowed
+		}
+		if allowed {
+			changes = append(changes, change)
+		}
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
 func (h *transactionHandler) getTotalPrice(c *gin.Context) {
 	userId, ok := auth.GetUserId(c)
 	if !ok {
@@ -216,11 +402,329 @@ func (h *transactionHandler) getTotalPrice(c *gin.Context) {
 		categoryId = 0
 	}
 
-	totalPrice, err := h.transactionRepository.GetTotalPriceByDateAndCategory(userId, year, month, day, categoryId)
+	tagIds, err := parseIdsParam(c.Query("tags"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag id: " + err.Error()})
+		return
+	}
+
+	excludeTagIds, err := parseIdsParam(c.Query("excludeTags"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tag id: " + err.Error()})
+		return
+	}
+
+	convertTo, err := h.getConvertTo(c, userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	filter := repository.TotalFilter{
+		Year:          year,
+		Month:         month,
+		Day:           day,
+		CategoryId:    categoryId,
+		TagIds:        tagIds,
+		ExcludeTagIds: excludeTagIds,
+		ConvertTo:     convertTo,
+	}
+
+	total, err := h.transactionRepository.GetTotalPriceByDateAndCategory(ledgerId, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get total price"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"totalPrice": totalPrice})
+	c.JSON(http.StatusOK, total)
+}
+
+type updateTransactionInput struct {
+	TransactionId int64       `json:"id"`
+	Price         int64       `json:"price"`
+	Kind          string      `json:"kind"`
+	Currency      string      `json:"currency"`
+	CategoryId    *int64      `json:"categoryId"`
+	AccountId     *int64      `json:"accountId"`
+	Date          string      `json:"date"`
+	Description   *string     `json:"description"`
+	Merchant      *string     `json:"merchant"`
+	Notes         *string     `json:"notes"`
+	Split         *splitInput `json:"split"`
+	TagIds        *[]int64    `json:"tagIds"`
+}
+
+// prepareTransaction validates a new transaction, fills in its defaults and
+// puts it in the ledger of its category. Splits are left to the caller.
+// It returns the status and message of the response to send on failure.
+func (h *transactionHandler) prepareTransaction(transaction *model.Transaction, tagIds []int64, userId int64) (int, string) {
+	category, err := h.transactionCategoryRepository.GetTransactionCategoryById(transaction.CategoryId)
+	if err != nil {
+		return http.StatusNotFound, "not found"
+	}
+	if status, message := h.ledgers.status(category.LedgerId, userId, model.LedgerRoleEditor); status != 0 {
+		return status, message
+	}
+	if category.ArchivedAt != nil {
+		return http.StatusBadRequest, "category is archived"
+	}
+
+	if transaction.Date != "" {
+		if _, err := utils.ParseDate(transaction.Date); err != nil {
+			return http.StatusBadRequest, "invalid date, expected RFC3339 or YYYY-MM-DD"
+		}
+	}
+
+	// Transfers come in pairs and are only made by /account/transfer, the
+	// direction of the rest is carried by the kind, so prices are positive.
+	if transaction.Kind == "" {
+		transaction.Kind = model.TransactionKindExpense
+	}
+	if transaction.Kind != model.TransactionKindExpense && transaction.Kind != model.TransactionKindIncome {
+		return http.StatusBadRequest, "invalid kind, expected expense or income"
+	}
+	if transaction.Price <= 0 {
+		return http.StatusBadRequest, "price must be positive"
+	}
+
+	if transaction.Currency != "" {
+		transaction.Currency, err = currency.Normalize(transaction.Currency)
+		if err != nil {
+			return http.StatusBadRequest, err.Error()
+		}
+	}
+
+	if transaction.AccountId != nil {
+		if status, message := h.checkAccount(transaction, userId); status != 0 {
+			return status, message
+		}
+	}
+
+	if status, message := h.checkTags(category.LedgerId, tagIds); status != 0 {
+		return status, message
+	}
+
+	transaction.UserId = userId
+	transaction.LedgerId = category.LedgerId
+	transaction.TransferId = nil
+	return 0, ""
+}
+
+// applyUpdate validates the changes to an existing transaction and applies
+// them to it. Splits are left to the caller.
+// It returns the status and message of the response to send on failure.
+func (h *transactionHandler) applyUpdate(transaction *model.Transaction, input updateTransactionInput, userId int64) (int, string) {
+	if transaction.TransferId != nil {
+		return http.StatusBadRequest, "transfers can't be edited, delete and create it again"
+	}
+	if transaction.Kind == model.TransactionKindSettlement {
+		return http.StatusBadRequest, "settlements can't be edited, delete and create it again"
+	}
+
+	var err error
+	if input.Price < 0 {
+		return http.StatusBadRequest, "price must be positive"
+	}
+	if input.Price != 0 {
+		transaction.Price = input.Price
+	}
+	if input.Kind != "" {
+		if input.Kind != model.TransactionKindExpense && input.Kind != model.TransactionKindIncome {
+			return http.StatusBadRequest, "invalid kind, expected expense or income"
+		}
+		transaction.Kind = input.Kind
+	}
+	if input.Currency != "" {
+		transaction.Currency, err = currency.Normalize(input.Currency)
+		if err != nil {
+			return http.StatusBadRequest, err.Error()
+		}
+	}
+	if input.CategoryId != nil && *input.CategoryId != transaction.CategoryId {
+		category, err := h.transactionCategoryRepository.GetTransactionCategoryById(*input.CategoryId)
+		if err != nil || category.LedgerId != transaction.LedgerId {
+			return http.StatusBadRequest, "category not found in the ledger"
+		}
+		if category.ArchivedAt != nil {
+			return http.StatusBadRequest, "category is archived"
+		}
+		transaction.CategoryId = category.Id
+	}
+	if input.Date != "" {
+		if _, err := utils.ParseDate(input.Date); err != nil {
+			return http.StatusBadRequest, "invalid date, expected RFC3339 or YYYY-MM-DD"
+		}
+		transaction.Date = input.Date
+	}
+	if input.AccountId != nil {
+		transaction.AccountId = input.AccountId
+		if *input.AccountId == 0 {
+			transaction.AccountId = nil
+		}
+	}
+	// Accounts are personal, so other ledger members can edit the transaction
+	// as long as they leave its account and currency alone.
+	if transaction.AccountId != nil && (input.AccountId != nil || input.Currency != "") {
+		if status, message := h.checkAccount(transaction, userId); status != 0 {
+			return status, message
+		}
+	}
+	if input.Description != nil {
+		transaction.Description = *input.Description
+	}
+	if input.Merchant != nil {
+		transaction.Merchant = *input.Merchant
+	}
+	if input.Notes != nil {
+		transaction.Notes = *input.Notes
+	}
+
+	if input.TagIds != nil {
+		if status, message := h.checkTags(transaction.LedgerId, *input.TagIds); status != 0 {
+			return status, message
+		}
+	}
+
+	return 0, ""
+}
+
+// checkAccount makes sure the transaction account belongs to the user and that
+// the transaction uses the account currency, defaulting to it when unset.
+// It returns the status and message of the response to send on failure.
+func (h *transactionHandler) checkAccount(transaction *model.Transaction, userId int64) (int, string) {
+	account, err := h.accountRepository.GetAccountById(*transaction.AccountId)
+	if err != nil || account.UserId != userId {
+		return http.StatusNotFound, "account not found"
+	}
+
+	if transaction.Currency == "" {
+		transaction.Currency = account.Currency
+	}
+	if transaction.Currency != account.Currency {
+		return http.StatusBadRequest, "transaction currency must match account currency " + account.Currency
+	}
+
+	return 0, ""
+}
+
+// checkTags makes sure all tags belong to the ledger of the transaction.
+// It returns the status and message of the response to send on failure.
+func (h *transactionHandler) checkTags(ledgerId int64, tagIds []int64) (int, string) {
+	for _, tagId := range tagIds {
+		tag, err := h.tagRepository.GetTagById(tagId)
+		if err != nil || tag.LedgerId != ledgerId {
+			return http.StatusBadRequest, "tag " + strconv.FormatInt(tagId, 10) + " not found in the ledger"
+		}
+	}
+
+	return 0, ""
+}
+
+// getConvertTo returns the user's base currency when the request asks for
+// converted amounts with convert=true, and an empty string otherwise.
+func (h *transactionHandler) getConvertTo(c *gin.Context, userId int64) (string, error) {
+	convertParam := c.Query("convert")
+	if convertParam == "" {
+		return "", nil
+	}
+
+	convert, err := strconv.ParseBool(convertParam)
+	if err != nil {
+		return "", errors.New("invalid convert parameter")
+	}
+	if !convert {
+		return "", nil
+	}
+
+	user, err := h.userRepository.FindById(userId)
+	if err != nil {
+		return "", errors.New("cannot resolve base currency")
+	}
+
+	return user.BaseCurrency, nil
+}
+
+// parseTransactionFilter reads the filters shared by the transaction list, the
+// export and bulk selections, query returning the value of a parameter.
+func parseTransactionFilter(query func(key string) string) (repository.TransactionFilter, error) {
+	filter := repository.TransactionFilter{
+		Search:   query("q"),
+		Merchant: strings.TrimSpace(query("merchant")),
+	}
+
+	var err error
+	if filter.CategoryIds, err = parseIdsParam(query("categoryIds")); err != nil {
+		return filter, errors.New("invalid categoryId: " + err.Error())
+	}
+	if filter.TagIds, err = parseIdsParam(query("tags")); err != nil {
+		return filter, errors.New("invalid tag id: " + err.Error())
+	}
+	if filter.ExcludeTagIds, err = parseIdsParam(query("excludeTags")); err != nil {
+		return filter, errors.New("invalid tag id: " + err.Error())
+	}
+
+	if fromParam := query("from"); fromParam != "" {
+		from, err := utils.ParseDate(fromParam)
+		if err != nil {
+			return filter, errors.New("invalid from parameter")
+		}
+		filter.From = &from
+	}
+
+	if toParam := query("to"); toParam != "" {
+		to, err := utils.ParseDateRangeEnd(toParam)
+		if err != nil {
+			return filter, errors.New("invalid to parameter")
+		}
+		filter.To = &to
+	}
+
+	if minParam := query("minAmount"); minParam != "" {
+		minPrice, err := strconv.ParseInt(minParam, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid minAmount parameter")
+		}
+		filter.MinPrice = &minPrice
+	}
+
+	if maxParam := query("maxAmount"); maxParam != "" {
+		maxPrice, err := strconv.ParseInt(maxParam, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid maxAmount parameter")
+		}
+		filter.MaxPrice = &maxPrice
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("minAmount cannot be greater than maxAmount")
+	}
+
+	if filter.Kind = query("kind"); filter.Kind != "" &&
+		!model.IsValidTransactionKind(filter.Kind) && filter.Kind != model.TransactionKindSettlement {
+		return filter, errors.New("invalid kind parameter")
+	}
+
+	return filter, nil
+}
+
+func parseIdsParam(param string) ([]int64, error) {
+	var ids []int64
+	if param == "" {
+		return ids, nil
+	}
+
+	for _, idStr := range strings.Split(param, ",") {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return nil, errors.New(idStr)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
 }
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/csvimport"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const importedCategoryColor = "#9e9e9e"
+
+type importResult struct {
+	DryRun        bool                  `json:"dryRun"`
+	Rows          []csvimport.Row       `json:"rows"`
+	Errors        []csvimport.LineError `json:"errors"`
+	NewCategories []string              `json:"newCategories"`
+	Imported      int                   `json:"imported"`
+}
+
+func (h *transactionHandler) importTransactions(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.importMaxSize)
+	if _, err := c.MultipartForm(); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide csv file in the file field"})
+		return
+	}
+
+	dryRunParam := c.Query("dryRun")
+	if dryRunParam == "" {
+		dryRunParam = c.PostForm("dryRun")
+	}
+	dryRun := false
+	if dryRunParam != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dryRun parameter"})
+			return
+		}
+	}
+
+	mapping := csvimport.DefaultMapping()
+	if mappingParam := c.PostForm("mapping"); mappingParam != "" {
+		if err := json.Unmarshal([]byte(mappingParam), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mapping object"})
+			return
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provide csv file in the file field"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read file"})
+		return
+	}
+	defer file.Close()
+
+	rows, lineErrors, err := csvimport.Parse(file, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ledgerIdParam := c.Query("ledgerId")
+	if ledgerIdParam == "" {
+		ledgerIdParam = c.PostForm("ledgerId")
+	}
+	ledgerId, ok := h.ledgers.resolve(c, ledgerIdParam, userId, model.LedgerRoleEditor)
+	if !ok {
+		return
+	}
+
+	categories, err := h.transactionCategoryRepository.GetTransactionCategories(ledgerId, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch categories"})
+		return
+	}
+
+	// Archived categories still hold their names, so rows naming them can't
+	// go to a new category either and are reported instead.
+	categoryIds := map[string]int64{}
+	archived := map[string]bool{}
+	for _, category := range categories {
+		if category.ArchivedAt != nil {
+			archived[strings.ToLower(category.Name)] = true
+			continue
+		}
+		categoryIds[strings.ToLower(category.Name)] = category.Id
+	}
+
+	newCategories := []string{}
+	for _, row := range rows {
+		key := strings.ToLower(row.Category)
+		if archived[key] {
+			lineErrors = append(lineErrors, csvimport.LineError{Line: row.Line, Error: "category " + row.Category + " is archived"})
+			continue
+		}
+		if _, exists := categoryIds[key]; !exists {
+			categoryIds[key] = 0
+			newCategories = append(newCategories, row.Category)
+		}
+	}
+
+	sort.SliceStable(lineErrors, func(i, j int) bool {
+		return lineErrors[i].Line < lineErrors[j].Line
+	})
+
+	result := importResult{
+		DryRun:        dryRun,
+		Rows:          rows,
+		Errors:        lineErrors,
+		NewCategories: newCategories,
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if len(lineErrors) > 0 {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		categoryRepository := h.transactionCategoryRepository.WithTx(tx)
+		transactionRepository := h.transactionRepository.WithTx(tx)
+
+		for _, name := range newCategories {
+			id, err := categoryRepository.CreateTransactionCategory(model.TransactionCategory{
+				UserId:   userId,
+				LedgerId: ledgerId,
+				Name:     name,
+				Color:    importedCategoryColor,
+			})
+			if err != nil {
+				return err
+			}
+			categoryIds[strings.ToLower(name)] = id
+		}
+
+		for _, row := range rows {
+			_, err := transactionRepository.CreateTransaction(model.Transaction{
+				Price:      row.Price,
+				CategoryId: categoryIds[strings.ToLower(row.Category)],
+				Date:       row.Date.Format(time.RFC3339),
+				LedgerId:   ledgerId,
+				UserId:     userId,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import transactions"})
+		return
+	}
+
+	result.Imported = len(rows)
+	c.JSON(http.StatusOK, result)
+}
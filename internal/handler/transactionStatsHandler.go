@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/utils"
+	"expenses_tracker/internal/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatsBuckets keeps zero-filled responses reasonably small.
+const maxStatsBuckets = 1000
+
+func (h *transactionHandler) getStats(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	from, err := utils.ParseDate(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing from parameter"})
+		return
+	}
+
+	to, err := utils.ParseDateRangeEnd(c.Query("to"))
+	if err != nil || !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing to parameter"})
+		return
+	}
+
+	groupBy := c.DefaultQuery("groupBy", repository.StatsGroupByMonth)
+	if !repository.IsValidStatsGroupBy(groupBy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid groupBy, expected day, week, month or year"})
+		return
+	}
+
+	buckets := 0
+	for start := repository.StatsPeriodStart(from, groupBy); start.Before(to); start = repository.StatsNextPeriod(start, groupBy) {
+		if buckets++; buckets > maxStatsBuckets {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "too many periods, use a shorter range or a bigger groupBy"})
+			return
+		}
+	}
+
+	byCategory := false
+	if byCategoryParam := c.Query("byCategory"); byCategoryParam != "" {
+		byCategory, err = strconv.ParseBool(byCategoryParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid byCategory parameter"})
+			return
+		}
+	}
+
+	categoryIds, err := parseIdsParam(c.Query("categoryIds"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid categoryId: " + err.Error()})
+		return
+	}
+
+	convertTo, err := h.getConvertTo(c, userId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	stats, err := h.transactionRepository.GetTransactionStats(ledgerId, repository.StatsFilter{
+		From:        from,
+		To:          to,
+		GroupBy:     groupBy,
+		ByCategory:  byCategory,
+		CategoryIds: categoryIds,
+		ConvertTo:   convertTo,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
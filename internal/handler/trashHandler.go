@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/jwt"
+	"expenses_tracker/internal/repository"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type trashHandler struct {
+	transactor                    repository.Transactor
+	transactionRepository         repository.TransactionRepository
+	transactionCategoryRepository repository.TransactionCategoryRepository
+	ledgers                       ledgerAccess
+}
+
+type trash struct {
+	Transactions []model.Transaction         `json:"transactions"`
+	Categories   []model.TransactionCategory `json:"categories"`
+}
+
+func RegisterTrashRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, transactionRepository repository.TransactionRepository, transactionCategoryRepository repository.TransactionCategoryRepository, ledgerRepository repository.LedgerRepository, userRepository repository.UserRepository) {
+	handler := trashHandler{
+		transactor:                    transactor,
+		transactionRepository:         transactionRepository,
+		transactionCategoryRepository: transactionCategoryRepository,
+		ledgers:                       ledgerAccess{ledgerRepository: ledgerRepository, userRepository: userRepository},
+	}
+
+	trashRouterGroup := router.Group("/trash").Use(auth.GetAuthMiddleware(jwtService))
+
+	trashRouterGroup.GET("", handler.get)
+	trashRouterGroup.POST("/transaction/restore", handler.restoreTransaction)
+	trashRouterGroup.POST("/category/restore", handler.restoreCategory)
+}
+
+func (h *trashHandler) get(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	ledgerId, ok := h.ledgers.resolve(c, c.Query("ledgerId"), userId, model.LedgerRoleViewer)
+	if !ok {
+		return
+	}
+
+	transactions, err := h.transactionRepository.GetDeletedTransactions(ledgerId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
+		return
+	}
+
+	categories, err := h.transactionCategoryRepository.GetDeletedCategories(ledgerId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, trash{Transactions: transactions, Categories: categories})
+}
+
+// restoreTransaction takes a transaction out of the trash, together with the
+// other side of a transfer. Its category has to be restored first.
+func (h *trashHandler) restoreTransaction(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type RestoreInput struct {
+		TransactionId int64 `json:"id" binding:"required"`
+	}
+
+	var input RestoreInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	transaction, err := h.transactionRepository.GetDeletedTransactionById(input.TransactionId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, transaction.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	if transaction.CategoryId != 0 {
+		if _, err := h.transactionCategoryRepository.GetTransactionCategoryById(transaction.CategoryId); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "the category of the transaction is in the trash, restore it first"})
+			return
+		}
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		return h.transactionRepository.WithTx(tx).RestoreTransaction(transaction.Id, userId)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+// restoreCategory takes a category out of the trash together with its budgets
+// and the transactions deleted along with it.
+func (h *trashHandler) restoreCategory(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type RestoreInput struct {
+		CategoryId int64 `json:"id" binding:"required"`
+	}
+
+	var input RestoreInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	category, err := h.transactionCategoryRepository.GetDeletedCategoryById(input.CategoryId)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if !h.ledgers.check(c, category.LedgerId, userId, model.LedgerRoleEditor) {
+		return
+	}
+
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		return h.transactionCategoryRepository.WithTx(tx).RestoreCategory(category.Id, userId)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
@@ -1,39 +1,83 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
 	"expenses_tracker/internal/model"
 	"expenses_tracker/internal/pkg/auth"
+	"expenses_tracker/internal/pkg/categorytemplate"
+	"expenses_tracker/internal/pkg/currency"
 	"expenses_tracker/internal/pkg/jwt"
 	"expenses_tracker/internal/pkg/password"
+	"expenses_tracker/internal/pkg/throttle"
 	"expenses_tracker/internal/repository"
 	"log"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const personalLedgerName = "Personal"
+
 type userHandler struct {
-	jwtService     *jwt.JwtService
-	userRepository repository.UserRepository
+	jwtService                    *jwt.JwtService
+	transactor                    repository.Transactor
+	userRepository                repository.UserRepository
+	ledgerRepository              repository.LedgerRepository
+	transactionCategoryRepository repository.TransactionCategoryRepository
+	sessionRepository             repository.SessionRepository
+	loginAttemptRepository        repository.LoginAttemptRepository
+	refreshTokenTtl               time.Duration
+	loginLimiter                  *throttle.Limiter
+	ipLimiter                     *throttle.Limiter
+	adminLogins                   []string
 }
 
-func RegisterUserRoutes(router *gin.Engine, jwtService *jwt.JwtService, userRepository repository.UserRepository) {
+func RegisterUserRoutes(router *gin.Engine, jwtService *jwt.JwtService, transactor repository.Transactor, userRepository repository.UserRepository, ledgerRepository repository.LedgerRepository, transactionCategoryRepository repository.TransactionCategoryRepository, sessionRepository repository.SessionRepository, loginAttemptRepository repository.LoginAttemptRepository, refreshTokenTtl time.Duration, loginLimiter *throttle.Limiter, ipLimiter *throttle.Limiter, adminLogins []string) {
 	handler := userHandler{
-		jwtService:     jwtService,
-		userRepository: userRepository,
+		jwtService:                    jwtService,
+		transactor:                    transactor,
+		userRepository:                userRepository,
+		ledgerRepository:              ledgerRepository,
+		transactionCategoryRepository: transactionCategoryRepository,
+		sessionRepository:             sessionRepository,
+		loginAttemptRepository:        loginAttemptRepository,
+		refreshTokenTtl:               refreshTokenTtl,
+		loginLimiter:                  loginLimiter,
+		ipLimiter:                     ipLimiter,
+		adminLogins:                   adminLogins,
 	}
 
 	userRouterGroup := router.Group("/user")
 
 	userRouterGroup.POST("/register", handler.register)
-	userRouterGroup.GET("/login", handler.login)
-	userRouterGroup.Use(auth.GetAuthMiddleware(jwtService)).GET("/", handler.get)
+	userRouterGroup.POST("/login", handler.login)
+	userRouterGroup.POST("/refresh", handler.refresh)
+
+	authorizedUserRouterGroup := userRouterGroup.Use(auth.GetAuthMiddleware(jwtService))
+	authorizedUserRouterGroup.GET("/", handler.get)
+	authorizedUserRouterGroup.PUT("/", handler.update)
+	authorizedUserRouterGroup.POST("/logout", handler.logout)
+	authorizedUserRouterGroup.POST("/logout-all", handler.logoutAll)
+	authorizedUserRouterGroup.GET("/login-attempts", handler.getLoginAttempts)
+	authorizedUserRouterGroup.GET("/sessions", handler.getSessions)
+	authorizedUserRouterGroup.DELETE("/sessions", handler.deleteSession)
 }
 
 func (h *userHandler) register(c *gin.Context) {
 	type RegisterInput struct {
-		Login    string `json:"login" binding:"required"`
-		Password string `json:"password" binding:"required"`
+		Login        string `json:"login" binding:"required"`
+		Password     string `json:"password" binding:"required"`
+		BaseCurrency string `json:"baseCurrency"`
+		// New users get the default category templates in their language
+		// unless they opt out or pick another set.
+		Locale           string `json:"locale"`
+		CategoryTemplate string `json:"categoryTemplate"`
+		SkipCategories   bool   `json:"skipCategories"`
 	}
 
 	var input RegisterInput
@@ -42,6 +86,40 @@ func (h *userHandler) register(c *gin.Context) {
 		return
 	}
 
+	// Admin logins are handed out by the operators, whoever registered one
+	// first would get admin rights.
+	if slices.Contains(h.adminLogins, input.Login) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "login is reserved"})
+		return
+	}
+
+	baseCurrency := currency.Default
+	if input.BaseCurrency != "" {
+		var err error
+		baseCurrency, err = currency.Normalize(input.BaseCurrency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var templates []categorytemplate.Category
+	if !input.SkipCategories {
+		if input.CategoryTemplate == "" {
+			input.CategoryTemplate = categorytemplate.DefaultSet
+		}
+		var found bool
+		templates, found = categorytemplate.Get(input.CategoryTemplate)
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown category template"})
+			return
+		}
+	}
+
+	if input.Locale == "" {
+		input.Locale = c.GetHeader("Accept-Language")
+	}
+
 	hashedPassword, err := password.HashPassword(input.Password)
 	if err != nil {
 		c.JSON(400, gin.H{
@@ -50,9 +128,27 @@ func (h *userHandler) register(c *gin.Context) {
 		return
 	}
 
-	err = h.userRepository.Create(model.UserModel{
-		PasswordHash: hashedPassword,
-		Login:        input.Login,
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		userRepository := h.userRepository.WithTx(tx)
+		userId, err := userRepository.Create(model.UserModel{
+			PasswordHash: hashedPassword,
+			Login:        input.Login,
+			BaseCurrency: baseCurrency,
+		})
+		if err != nil {
+			return err
+		}
+
+		ledgerId, err := h.ledgerRepository.WithTx(tx).CreateLedger(personalLedgerName, userId)
+		if err != nil {
+			return err
+		}
+		if err := userRepository.UpdateDefaultLedger(userId, ledgerId); err != nil {
+			return err
+		}
+
+		_, err = applyCategoryTemplates(h.transactionCategoryRepository.WithTx(tx), userId, ledgerId, templates, categorytemplate.Locale(input.Locale))
+		return err
 	})
 	if err != nil {
 		c.JSON(400, gin.H{
@@ -61,37 +157,283 @@ func (h *userHandler) register(c *gin.Context) {
 		return
 	}
 
-	log.Println("user", input)
 	c.JSON(200, gin.H{
 		"status": "ok",
 	})
 }
 
 func (h *userHandler) login(c *gin.Context) {
-	login, passwordParam := c.Query("login"), c.Query("password")
-	if login == "" || passwordParam == "" {
+	type LoginInput struct {
+		Login    string `json:"login" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	var input LoginInput
+	if err := c.BindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "provide login and password"})
 		return
 	}
 
-	user, err := h.userRepository.FindByLogin(login)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found", "hi": err.Error()})
+	// The attempt is reserved before the slow password check, so parallel
+	// guesses can't all pass the limiters.
+	ip := c.ClientIP()
+	lockout := h.loginLimiter.Acquire(input.Login)
+	if lockout == 0 {
+		if lockout = h.ipLimiter.Acquire(ip); lockout > 0 {
+			h.loginLimiter.Release(input.Login)
+		}
+	}
+	if lockout > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockout.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed attempts, try again later"})
 		return
 	}
 
-	if !password.ComparePassword(user.PasswordHash, passwordParam) {
+	user, err := h.userRepository.FindByLogin(input.Login)
+	if err != nil || !password.ComparePassword(user.PasswordHash, input.Password) {
+		h.loginLimiter.Fail(input.Login)
+		h.ipLimiter.Fail(ip)
+		if err == nil {
+			if err := h.loginAttemptRepository.CreateLoginAttempt(user.Id, ip, c.Request.UserAgent()); err != nil {
+				log.Println("failed to record login attempt:", err)
+			}
+		}
+
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	token, err := h.jwtService.GenerateToken(user.Id)
+	h.loginLimiter.Reset(input.Login)
+	h.ipLimiter.Release(ip)
+
+	sessionId, err := jwt.GenerateSessionId()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot generate token"})
+		return
+	}
+
+	var refreshToken string
+	err = h.transactor.InTransaction(func(tx *sql.Tx) error {
+		sessionRepository := h.sessionRepository.WithTx(tx)
+		err := sessionRepository.CreateSession(model.Session{
+			Id:        sessionId,
+			UserId:    user.Id,
+			UserAgent: c.Request.UserAgent(),
+		}, time.Now().Add(h.refreshTokenTtl))
+		if err != nil {
+			return err
+		}
+
+		refreshToken, err = h.issueRefreshToken(sessionRepository, sessionId)
+		return err
+	})
 	if err != nil {
-		print(err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot generate token"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": token})
+
+	h.respondWithTokens(c, user.Id, sessionId, refreshToken)
+}
+
+// refresh exchanges a refresh token for a new pair of tokens. Every refresh
+// token works once, presenting a used one means it has leaked, so the whole
+// session gets revoked.
+func (h *userHandler) refresh(c *gin.Context) {
+	type RefreshInput struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+
+	var input RefreshInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	var session model.Session
+	var refreshToken string
+	reused := false
+	err := h.transactor.InTransaction(func(tx *sql.Tx) error {
+		sessionRepository := h.sessionRepository.WithTx(tx)
+		token, err := sessionRepository.FindRefreshToken(jwt.HashRefreshToken(input.RefreshToken))
+		if err != nil {
+			return err
+		}
+
+		active, err := sessionRepository.IsSessionActive(token.SessionId)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return jwt.ErrSessionRevoked
+		}
+
+		session, err = sessionRepository.GetSessionById(token.SessionId)
+		if err != nil {
+			return err
+		}
+
+		fresh, err := sessionRepository.UseRefreshToken(token.TokenHash)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			reused = true
+			return sessionRepository.RevokeSession(session.Id)
+		}
+
+		if err := sessionRepository.ExtendSession(session.Id, time.Now().Add(h.refreshTokenTtl)); err != nil {
+			return err
+		}
+
+		refreshToken, err = h.issueRefreshToken(sessionRepository, session.Id)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, jwt.ErrSessionRevoked) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot refresh token"})
+		return
+	}
+	if reused {
+		log.Println("refresh token reuse, revoked session", session.Id)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected, session is revoked"})
+		return
+	}
+
+	h.respondWithTokens(c, session.UserId, session.Id, refreshToken)
+}
+
+func (h *userHandler) issueRefreshToken(sessionRepository repository.SessionRepository, sessionId string) (string, error) {
+	refreshToken, refreshTokenHash, err := jwt.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, sessionRepository.CreateRefreshToken(refreshTokenHash, sessionId)
+}
+
+func (h *userHandler) respondWithTokens(c *gin.Context, userId int64, sessionId string, refreshToken string) {
+	token, err := h.jwtService.GenerateToken(userId, sessionId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":        token,
+		"refreshToken": refreshToken,
+	})
+}
+
+func (h *userHandler) logout(c *gin.Context) {
+	sessionId, ok := auth.GetSessionId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.sessionRepository.RevokeSession(sessionId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *userHandler) logoutAll(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.sessionRepository.RevokeUserSessions(userId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
+
+func (h *userHandler) getLoginAttempts(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	page, err := strconv.ParseInt(c.Query("page"), 10, 64)
+	if err != nil || page <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing page parameter"})
+		return
+	}
+
+	items, err := strconv.ParseInt(c.Query("items"), 10, 64)
+	if err != nil || items <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing items parameter"})
+		return
+	}
+
+	pagination := repository.Pagination{
+		Page:  page,
+		Items: items,
+	}
+
+	attempts, err := h.loginAttemptRepository.GetLoginAttempts(userId, repository.ResolvePagination(&pagination))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch login attempts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+func (h *userHandler) getSessions(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	sessions, err := h.sessionRepository.GetActiveSessions(userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *userHandler) deleteSession(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type DeleteSessionInput struct {
+		SessionId string `json:"id" binding:"required"`
+	}
+
+	var input DeleteSessionInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	session, err := h.sessionRepository.GetSessionById(input.SessionId)
+	if err != nil || session.UserId != userId {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	if err := h.sessionRepository.RevokeSession(session.Id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
 }
 
 func (h *userHandler) get(c *gin.Context) {
@@ -109,3 +451,35 @@ func (h *userHandler) get(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+func (h *userHandler) update(c *gin.Context) {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	type UpdateUserInput struct {
+		BaseCurrency string `json:"baseCurrency" binding:"required"`
+	}
+
+	var input UpdateUserInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input object"})
+		return
+	}
+
+	baseCurrency, err := currency.Normalize(input.BaseCurrency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.userRepository.UpdateBaseCurrency(userId, baseCurrency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update"})
+		return
+	}
+
+	c.String(http.StatusOK, "OK")
+}
@@ -0,0 +1,29 @@
+package model
+
+type Account struct {
+	Id             int64  `json:"id"`
+	UserId         int64  `json:"userId"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Currency       string `json:"currency"`
+	OpeningBalance int64  `json:"openingBalance"`
+	Balance        int64  `json:"balance"`
+	CreatedAt      string `json:"createdAt"`
+}
+
+type AccountStatementEntry struct {
+	TransactionId int64  `json:"transactionId"`
+	Kind          string `json:"kind"`
+	Price         int64  `json:"price"`
+	Date          string `json:"date"`
+	Description   string `json:"description"`
+	Balance       int64  `json:"balance"`
+}
+
+func IsValidAccountType(accountType string) bool {
+	switch accountType {
+	case "cash", "card", "savings", "other":
+		return true
+	}
+	return false
+}
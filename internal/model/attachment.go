@@ -0,0 +1,27 @@
+package model
+
+// Attachment is a file kept with a transaction, such as a photo of a receipt.
+type Attachment struct {
+	Id            int64  `json:"id"`
+	TransactionId int64  `json:"transactionId"`
+	UserId        int64  `json:"userId"`
+	FileName      string `json:"fileName"`
+	ContentType   string `json:"contentType"`
+	Size          int64  `json:"size"`
+	Hash          string `json:"sha256"`
+	CreatedAt     string `json:"createdAt"`
+}
+
+var attachmentContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// IsAllowedAttachmentType tells whether files of the sniffed content type can
+// be attached.
+func IsAllowedAttachmentType(contentType string) bool {
+	return attachmentContentTypes[contentType]
+}
@@ -0,0 +1,26 @@
+package model
+
+type Budget struct {
+	Id         int64 `json:"id"`
+	UserId     int64 `json:"userId"`
+	CategoryId int64 `json:"categoryId"`
+	Year       int   `json:"year"`
+	Month      int   `json:"month"`
+	Amount     int64 `json:"amount"`
+	Rollover   bool  `json:"rollover"`
+}
+
+type BudgetStatus struct {
+	BudgetId   int64   `json:"budgetId"`
+	CategoryId int64   `json:"categoryId"`
+	Amount     int64   `json:"amount"`
+	RolledOver float64 `json:"rolledOver"`
+	Limit      float64 `json:"limit"`
+	Spent      float64 `json:"spent"`
+	Remaining  float64 `json:"remaining"`
+	Percent    float64 `json:"percent"`
+	OverBudget bool    `json:"overBudget"`
+	// MissingRates counts spendings left out of Spent, and of the rolled over
+	// months, because no exchange rate was known on their date.
+	MissingRates int64 `json:"missingRates,omitempty"`
+}
@@ -0,0 +1,9 @@
+package model
+
+type ExchangeRate struct {
+	Id   int64   `json:"id"`
+	Date string  `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
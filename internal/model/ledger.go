@@ -0,0 +1,50 @@
+package model
+
+const (
+	LedgerRoleOwner  = "owner"
+	LedgerRoleEditor = "editor"
+	LedgerRoleViewer = "viewer"
+)
+
+var ledgerRoleRanks = map[string]int{
+	LedgerRoleViewer: 1,
+	LedgerRoleEditor: 2,
+	LedgerRoleOwner:  3,
+}
+
+type Ledger struct {
+	Id        int64  `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	IsDefault bool   `json:"isDefault"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type LedgerMember struct {
+	LedgerId  int64  `json:"ledgerId"`
+	UserId    int64  `json:"userId"`
+	Login     string `json:"login"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type LedgerInvitation struct {
+	Id         int64  `json:"id"`
+	LedgerId   int64  `json:"ledgerId"`
+	LedgerName string `json:"ledgerName"`
+	UserId     int64  `json:"userId"`
+	Role       string `json:"role"`
+	InvitedBy  string `json:"invitedBy"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+func IsValidLedgerRole(role string) bool {
+	_, ok := ledgerRoleRanks[role]
+	return ok
+}
+
+// HasLedgerRole tells whether role grants at least what minRole does. Owners
+// can do everything editors can, and editors everything viewers can.
+func HasLedgerRole(role string, minRole string) bool {
+	return ledgerRoleRanks[role] >= ledgerRoleRanks[minRole] && ledgerRoleRanks[role] > 0
+}
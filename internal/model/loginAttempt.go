@@ -0,0 +1,8 @@
+package model
+
+type LoginAttempt struct {
+	Id        int64  `json:"id"`
+	Ip        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	CreatedAt string `json:"createdAt"`
+}
@@ -0,0 +1,34 @@
+package model
+
+const (
+	OccurrenceStatusPosted  = "posted"
+	OccurrenceStatusSkipped = "skipped"
+)
+
+type RecurringTransaction struct {
+	Id          int64   `json:"id"`
+	UserId      int64   `json:"userId"`
+	CategoryId  int64   `json:"categoryId"`
+	AccountId   *int64  `json:"accountId"`
+	Kind        string  `json:"kind"`
+	Price       int64   `json:"price"`
+	Currency    string  `json:"currency"`
+	Description string  `json:"description"`
+	Merchant    string  `json:"merchant"`
+	Frequency   string  `json:"frequency"`
+	Interval    int     `json:"interval"`
+	StartDate   string  `json:"startDate"`
+	EndDate     *string `json:"endDate"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
+type UpcomingOccurrence struct {
+	RecurringTransactionId int64  `json:"recurringTransactionId"`
+	Date                   string `json:"date"`
+	Kind                   string `json:"kind"`
+	Price                  int64  `json:"price"`
+	Currency               string `json:"currency"`
+	CategoryId             int64  `json:"categoryId"`
+	Description            string `json:"description"`
+	Skipped                bool   `json:"skipped"`
+}
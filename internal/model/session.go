@@ -0,0 +1,17 @@
+package model
+
+type Session struct {
+	Id         string  `json:"id"`
+	UserId     int64   `json:"userId"`
+	UserAgent  string  `json:"userAgent"`
+	CreatedAt  string  `json:"createdAt"`
+	LastUsedAt string  `json:"lastUsedAt"`
+	ExpiresAt  string  `json:"expiresAt"`
+	RevokedAt  *string `json:"revokedAt,omitempty"`
+}
+
+type RefreshToken struct {
+	TokenHash string
+	SessionId string
+	UsedAt    *string
+}
@@ -0,0 +1,8 @@
+package model
+
+type Tag struct {
+	Id        int64  `json:"id"`
+	LedgerId  int64  `json:"ledgerId"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+}
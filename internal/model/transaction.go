@@ -1,10 +1,34 @@
 package model
 
+const (
+	TransactionKindExpense    = "expense"
+	TransactionKindIncome     = "income"
+	TransactionKindTransfer   = "transfer"
+	TransactionKindSettlement = "settlement"
+)
+
 type Transaction struct {
-	Id         int64               `json:"id"`
-	Price      int64               `json:"price"`
-	CategoryId int64               `json:"categoryId"`
-	CreatedAt  string              `json:"createdAt"`
-	UserId     int64               `json:"userId"`
-	Category   TransactionCategory `json:"category"`
+	Id             int64               `json:"id"`
+	Kind           string              `json:"kind"`
+	Price          int64               `json:"price"`
+	Currency       string              `json:"currency"`
+	ConvertedPrice *float64            `json:"convertedPrice,omitempty"`
+	CategoryId     int64               `json:"categoryId"`
+	AccountId      *int64              `json:"accountId"`
+	TransferId     *int64              `json:"transferId,omitempty"`
+	Date           string              `json:"date"`
+	Description    string              `json:"description"`
+	Merchant       string              `json:"merchant"`
+	Notes          string              `json:"notes"`
+	CreatedAt      string              `json:"createdAt"`
+	DeletedAt      *string             `json:"deletedAt,omitempty"`
+	LedgerId       int64               `json:"ledgerId"`
+	UserId         int64               `json:"userId"`
+	Category       TransactionCategory `json:"category"`
+	Splits         []TransactionSplit  `json:"splits,omitempty"`
+	Tags           []Tag               `json:"tags,omitempty"`
+}
+
+func IsValidTransactionKind(kind string) bool {
+	return kind == TransactionKindExpense || kind == TransactionKindIncome || kind == TransactionKindTransfer
 }
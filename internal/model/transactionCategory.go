@@ -1,8 +1,40 @@
 package model
 
+import "regexp"
+
+// What happens to transactions of a deleted category.
+const (
+	CategoryDeleteModeRefuse   = "refuse"
+	CategoryDeleteModeReassign = "reassign"
+	CategoryDeleteModeCascade  = "cascade"
+)
+
+var (
+	categoryColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	categoryIconPattern  = regexp.MustCompile(`^[a-z0-9_-]{0,64}$`)
+)
+
 type TransactionCategory struct {
-	Id     int64  `json:"id"`
-	UserId int64  `json:"userId"`
-	Name   string `json:"name"`
-	Color  string `json:"color"`
+	Id         int64                 `json:"id"`
+	UserId     int64                 `json:"userId"`
+	LedgerId   int64                 `json:"ledgerId"`
+	ParentId   *int64                `json:"parentId"`
+	Name       string                `json:"name"`
+	Color      string                `json:"color"`
+	Icon       string                `json:"icon"`
+	SortOrder  int64                 `json:"sortOrder"`
+	ArchivedAt *string               `json:"archivedAt"`
+	DeletedAt  *string               `json:"deletedAt,omitempty"`
+	Children   []TransactionCategory `json:"children,omitempty"`
+}
+
+// IsValidCategoryColor accepts hex colors like #fa0 or #ffaa00.
+func IsValidCategoryColor(color string) bool {
+	return categoryColorPattern.MatchString(color)
+}
+
+// IsValidCategoryIcon accepts icon names made of lowercase letters, digits,
+// dashes and underscores, or no icon at all.
+func IsValidCategoryIcon(icon string) bool {
+	return categoryIconPattern.MatchString(icon)
 }
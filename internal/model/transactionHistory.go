@@ -0,0 +1,26 @@
+package model
+
+const (
+	TransactionActionCreate  = "create"
+	TransactionActionUpdate  = "update"
+	TransactionActionDelete  = "delete"
+	TransactionActionRestore = "restore"
+)
+
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// TransactionChange is one entry of the history of a transaction, Changes
+// holding the fields it changed by their JSON names.
+type TransactionChange struct {
+	Id            int64                  `json:"id"`
+	TransactionId int64                  `json:"transactionId"`
+	LedgerId      int64                  `json:"ledgerId"`
+	UserId        int64                  `json:"userId"`
+	Login         string                 `json:"login"`
+	Action        string                 `json:"action"`
+	Changes       map[string]FieldChange `json:"changes"`
+	CreatedAt     string                 `json:"createdAt"`
+}
@@ -0,0 +1,27 @@
+package model
+
+type TransactionSplit struct {
+	UserId int64 `json:"userId"`
+	Amount int64 `json:"amount"`
+}
+
+// Balance is what a member is owed in one currency, negative when they owe.
+type Balance struct {
+	UserId   int64  `json:"userId"`
+	Login    string `json:"login"`
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+type Debt struct {
+	FromUserId int64  `json:"fromUserId"`
+	ToUserId   int64  `json:"toUserId"`
+	Currency   string `json:"currency"`
+	Amount     int64  `json:"amount"`
+}
+
+type LedgerBalances struct {
+	LedgerId int64     `json:"ledgerId"`
+	Balances []Balance `json:"balances"`
+	Debts    []Debt    `json:"debts"`
+}
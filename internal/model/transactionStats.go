@@ -0,0 +1,26 @@
+package model
+
+type TransactionStats struct {
+	GroupBy  string                   `json:"groupBy"`
+	Currency string                   `json:"currency,omitempty"`
+	Buckets  []TransactionStatsBucket `json:"buckets"`
+	// MissingRates counts transactions left out of converted sums because no
+	// exchange rate was known on their date.
+	MissingRates int64 `json:"missingRates,omitempty"`
+}
+
+type TransactionStatsBucket struct {
+	// Period is the first day of the bucket, weeks start on Monday.
+	Period     string                     `json:"period"`
+	Income     float64                    `json:"income"`
+	Expense    float64                    `json:"expense"`
+	Net        float64                    `json:"net"`
+	Categories []TransactionStatsCategory `json:"categories,omitempty"`
+}
+
+type TransactionStatsCategory struct {
+	CategoryId int64   `json:"categoryId"`
+	Income     float64 `json:"income"`
+	Expense    float64 `json:"expense"`
+	Net        float64 `json:"net"`
+}
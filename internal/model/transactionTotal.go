@@ -0,0 +1,13 @@
+package model
+
+type TransactionTotal struct {
+	// TotalPrice is the same as Expense and is kept for older clients.
+	TotalPrice float64 `json:"totalPrice"`
+	Income     float64 `json:"income"`
+	Expense    float64 `json:"expense"`
+	Net        float64 `json:"net"`
+	Currency   string  `json:"currency,omitempty"`
+	// MissingRates counts transactions left out of a converted total because
+	// no exchange rate was known on their date.
+	MissingRates int64 `json:"missingRates,omitempty"`
+}
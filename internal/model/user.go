@@ -1,7 +1,9 @@
 package model
 
 type UserModel struct {
-	Id           int64
-	Login        string
-	PasswordHash string
+	Id              int64
+	Login           string
+	PasswordHash    string
+	BaseCurrency    string
+	DefaultLedgerId int64
 }
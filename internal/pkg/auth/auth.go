@@ -19,13 +19,14 @@ func GetAuthMiddleware(jwtService *jwt.JwtService) gin.HandlerFunc {
 
 		token := strings.TrimPrefix(headerValue, "Bearer ")
 
-		userId, err := jwtService.VerifyToken(token)
+		claims, err := jwtService.VerifyToken(token)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token invalid"})
 			return
 		}
 
-		c.Set("UserId", userId)
+		c.Set("UserId", claims.UserId)
+		c.Set("SessionId", claims.SessionId())
 		c.Next()
 	}
 }
@@ -39,3 +40,13 @@ func GetUserId(c *gin.Context) (int64, bool) {
 	id, ok := userId.(int64)
 	return id, ok
 }
+
+func GetSessionId(c *gin.Context) (string, bool) {
+	sessionId, exists := c.Get("SessionId")
+	if !exists {
+		return "", false
+	}
+
+	id, ok := sessionId.(string)
+	return id, ok && id != ""
+}
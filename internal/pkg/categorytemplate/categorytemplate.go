@@ -0,0 +1,73 @@
+package categorytemplate
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultSet    = "default"
+	DefaultLocale = "en"
+)
+
+//go:embed templates.json
+var templatesJson []byte
+
+// Category is a template of a category with its name in every supported
+// locale.
+type Category struct {
+	Names    map[string]string `json:"names"`
+	Color    string            `json:"color"`
+	Icon     string            `json:"icon"`
+	Children []Category        `json:"children"`
+}
+
+var sets = mustParse(templatesJson)
+
+func mustParse(data []byte) map[string][]Category {
+	parsed := map[string][]Category{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		panic("categorytemplate: invalid templates.json: " + err.Error())
+	}
+	return parsed
+}
+
+// Sets returns the names of all template sets.
+func Sets() []string {
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Get(set string) ([]Category, bool) {
+	categories, ok := sets[set]
+	return categories, ok
+}
+
+// Name returns the name in locale, or in DefaultLocale when the template has
+// no translation for it.
+func (c Category) Name(locale string) string {
+	if name, ok := c.Names[locale]; ok {
+		return name
+	}
+	return c.Names[DefaultLocale]
+}
+
+// Locale picks the language of a locale like "de-AT" or of the first entry of
+// an Accept-Language header like "ru-RU,ru;q=0.9". It falls back to
+// DefaultLocale for empty values.
+func Locale(value string) string {
+	value, _, _ = strings.Cut(value, ",")
+	value, _, _ = strings.Cut(value, ";")
+	value = strings.ReplaceAll(strings.TrimSpace(value), "_", "-")
+	value, _, _ = strings.Cut(value, "-")
+	if value == "" || value == "*" {
+		return DefaultLocale
+	}
+	return strings.ToLower(value)
+}
@@ -0,0 +1,194 @@
+package csvimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"expenses_tracker/internal/pkg/utils"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Mapping tells which CSV columns (by header name) hold the transaction fields.
+type Mapping struct {
+	Date      string `json:"date"`
+	Amount    string `json:"amount"`
+	Category  string `json:"category"`
+	Delimiter string `json:"delimiter"`
+	// DateFormat is a Go time layout. When empty, RFC3339 and YYYY-MM-DD are accepted.
+	DateFormat string `json:"dateFormat"`
+	// AmountMultiplier converts amounts to the stored integer price,
+	// e.g. 100 for statements that use "45.99".
+	AmountMultiplier int64 `json:"amountMultiplier"`
+}
+
+type Row struct {
+	Line     int       `json:"line"`
+	Date     time.Time `json:"date"`
+	Price    int64     `json:"price"`
+	Category string    `json:"category"`
+}
+
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func DefaultMapping() Mapping {
+	return Mapping{
+		Date:             "date",
+		Amount:           "amount",
+		Category:         "category",
+		Delimiter:        ",",
+		AmountMultiplier: 1,
+	}
+}
+
+// Parse reads the whole file and returns the rows that could be parsed along
+// with an error for every line that could not. The returned error is only set
+// when the file as a whole is unusable, e.g. a mapped column is missing.
+func Parse(r io.Reader, mapping Mapping) ([]Row, []LineError, error) {
+	rows := []Row{}
+	lineErrors := []LineError{}
+
+	delimiter, size := utf8.DecodeRuneInString(mapping.Delimiter)
+	if size == 0 || size != len(mapping.Delimiter) {
+		return nil, nil, errors.New("delimiter must be a single character")
+	}
+	if mapping.AmountMultiplier <= 0 {
+		return nil, nil, errors.New("amountMultiplier must be positive")
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read header: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	dateIndex, err := columnIndex(header, mapping.Date)
+	if err != nil {
+		return nil, nil, err
+	}
+	amountIndex, err := columnIndex(header, mapping.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	categoryIndex, err := columnIndex(header, mapping.Category)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				lineErrors = append(lineErrors, LineError{Line: parseErr.Line, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		row, err := parseRecord(record, dateIndex, amountIndex, categoryIndex, mapping)
+		if err != nil {
+			lineErrors = append(lineErrors, LineError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		row.Line = line
+		rows = append(rows, row)
+	}
+
+	return rows, lineErrors, nil
+}
+
+func parseRecord(record []string, dateIndex int, amountIndex int, categoryIndex int, mapping Mapping) (Row, error) {
+	var row Row
+
+	for _, index := range []int{dateIndex, amountIndex, categoryIndex} {
+		if index >= len(record) {
+			return row, errors.New("not enough columns")
+		}
+	}
+
+	date, err := parseDate(strings.TrimSpace(record[dateIndex]), mapping.DateFormat)
+	if err != nil {
+		return row, fmt.Errorf("invalid date %q", record[dateIndex])
+	}
+
+	price, err := parseAmount(strings.TrimSpace(record[amountIndex]), mapping.AmountMultiplier)
+	if err != nil {
+		return row, err
+	}
+
+	category := strings.TrimSpace(record[categoryIndex])
+	if category == "" {
+		return row, errors.New("empty category")
+	}
+
+	row.Date = date
+	row.Price = price
+	row.Category = category
+	return row, nil
+}
+
+func parseDate(value string, layout string) (time.Time, error) {
+	if layout == "" {
+		return utils.ParseDate(value)
+	}
+	return time.Parse(layout, value)
+}
+
+func parseAmount(value string, multiplier int64) (int64, error) {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	scaled := amount * float64(multiplier)
+	price := math.Round(scaled)
+	// float64(math.MaxInt64) rounds up to 2^63, which no longer fits.
+	if math.Abs(price) >= math.MaxInt64 {
+		return 0, fmt.Errorf("amount %q is out of range", value)
+	}
+	if math.Abs(scaled-price) > 1e-6 {
+		return 0, fmt.Errorf("amount %q has more precision than the multiplier allows", value)
+	}
+	if price == 0 {
+		return 0, errors.New("amount must not be zero")
+	}
+	// Every imported row is an expense, a negative one would lower the
+	// expense totals instead of adding to them.
+	if price < 0 {
+		return 0, fmt.Errorf("amount %q is negative, import spendings as positive amounts", value)
+	}
+
+	return int64(price), nil
+}
+
+func columnIndex(header []string, name string) (int, error) {
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), strings.TrimSpace(name)) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("column %q not found in header", name)
+}
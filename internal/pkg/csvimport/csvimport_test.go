@@ -0,0 +1,136 @@
+package csvimport
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value      string
+		multiplier int64
+		want       int64
+		wantErr    bool
+	}{
+		{"42", 1, 42, false},
+		{"45.99", 100, 4599, false},
+		{"0.1", 10, 1, false},
+		{"1e3", 1, 1000, false},
+		{"45.999", 100, 0, true},
+		{"0", 1, 0, true},
+		{"-12", 1, 0, true},
+		{"abc", 1, 0, true},
+		{"", 1, 0, true},
+		{"NaN", 1, 0, true},
+		{"Inf", 1, 0, true},
+		{"-Inf", 1, 0, true},
+		{"1e30", 1, 0, true},
+		{"9223372036854775807", 1, 0, true},
+		{"1e17", 100, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAmount(tt.value, tt.multiplier)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAmount(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAmount(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	day := func(value string) time.Time {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return date
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		mapping    Mapping
+		wantRows   []Row
+		wantErrors []LineError
+		wantErr    bool
+	}{
+		{
+			name:     "default mapping",
+			input:    "date,amount,category\n2024-01-02,12,Food\n2024-01-03,7,Rent\n",
+			mapping:  DefaultMapping(),
+			wantRows: []Row{{Line: 2, Date: day("2024-01-02"), Price: 12, Category: "Food"}, {Line: 3, Date: day("2024-01-03"), Price: 7, Category: "Rent"}},
+		},
+		{
+			name:     "custom columns, delimiter, format and multiplier",
+			input:    "\ufeffBooked;Sum;Kind;Note\n02.01.2024;45.99;Food;lunch\n",
+			mapping:  Mapping{Date: "booked", Amount: "sum", Category: "kind", Delimiter: ";", DateFormat: "02.01.2006", AmountMultiplier: 100},
+			wantRows: []Row{{Line: 2, Date: day("2024-01-02"), Price: 4599, Category: "Food"}},
+		},
+		{
+			name:     "line errors keep the other rows",
+			input:    "date,amount,category\n2024-01-02,12,Food\nyesterday,5,Food\n2024-01-03,NaN,Food\n2024-01-04,-3,Food\n2024-01-05,4,\n2024-01-06\n\n2024-01-07,1,Rent\n",
+			mapping:  DefaultMapping(),
+			wantRows: []Row{{Line: 2, Date: day("2024-01-02"), Price: 12, Category: "Food"}, {Line: 9, Date: day("2024-01-07"), Price: 1, Category: "Rent"}},
+			wantErrors: []LineError{
+				{Line: 3, Error: `invalid date "yesterday"`},
+				{Line: 4, Error: `invalid amount "NaN"`},
+				{Line: 5, Error: `amount "-3" is negative, import spendings as positive amounts`},
+				{Line: 6, Error: "empty category"},
+				{Line: 7, Error: "not enough columns"},
+			},
+		},
+		{
+			name:    "missing column",
+			input:   "date,price,category\n",
+			mapping: DefaultMapping(),
+			wantErr: true,
+		},
+		{
+			name:    "empty file",
+			input:   "",
+			mapping: DefaultMapping(),
+			wantErr: true,
+		},
+		{
+			name:    "long delimiter",
+			input:   "date,amount,category\n",
+			mapping: Mapping{Date: "date", Amount: "amount", Category: "category", Delimiter: ",,", AmountMultiplier: 1},
+			wantErr: true,
+		},
+		{
+			name:    "zero multiplier",
+			input:   "date,amount,category\n",
+			mapping: Mapping{Date: "date", Amount: "amount", Category: "category", Delimiter: ","},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, lineErrors, err := Parse(strings.NewReader(tt.input), tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if tt.wantErrors == nil {
+				tt.wantErrors = []LineError{}
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows = %v, want %v", rows, tt.wantRows)
+			}
+			if !reflect.DeepEqual(lineErrors, tt.wantErrors) {
+				t.Errorf("line errors = %v, want %v", lineErrors, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package currency
+
+import (
+	"errors"
+	"strings"
+)
+
+const Default = "USD"
+
+var ErrInvalidCode = errors.New("currency must be a three-letter ISO 4217 code")
+
+// Normalize upper-cases an ISO 4217 code and checks that it looks like one.
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", ErrInvalidCode
+	}
+
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", ErrInvalidCode
+		}
+	}
+
+	return code, nil
+}
@@ -1,13 +1,35 @@
 package jwt
 
 import (
+	"errors"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+const defaultAccessTokenTtl = 15 * time.Minute
+
+var (
+	ErrSessionRevoked = errors.New("session is revoked or expired")
+	ErrUnknownKey     = errors.New("token is signed with an unknown key")
+)
+
+// SessionValidator tells whether the session a token was issued for is still
+// active, so revoked tokens stop working before they expire.
+type SessionValidator interface {
+	IsSessionActive(sessionId string) (bool, error)
+}
+
+// JwtService signs tokens with SigningKey when it is set and with the HS256
+// PrivateKey secret otherwise. Tokens are accepted when signed by any of the
+// signing, verification or secret keys, so keys can be rotated without
+// logging users out.
 type JwtService struct {
-	PrivateKey string
+	PrivateKey       string
+	SigningKey       *Key
+	VerificationKeys []*Key
+	AccessTokenTtl   time.Duration
+	Sessions         SessionValidator
 }
 
 type Claims struct {
@@ -15,29 +37,90 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
-func (s *JwtService) GenerateToken(userId int64) (string, error) {
-	expirationTime := time.Now().Add(9 * time.Hour)
+func (c *Claims) SessionId() string {
+	return c.Id
+}
+
+func (s *JwtService) GenerateToken(userId int64, sessionId string) (string, error) {
+	ttl := s.AccessTokenTtl
+	if ttl == 0 {
+		ttl = defaultAccessTokenTtl
+	}
+
+	now := time.Now()
+	expirationTime := now.Add(ttl)
 	claims := &Claims{
 		UserId: userId,
 		StandardClaims: jwt.StandardClaims{
+			Id:        sessionId,
+			IssuedAt:  now.Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
 
+	if s.SigningKey != nil {
+		token := jwt.NewWithClaims(s.SigningKey.Method, claims)
+		token.Header["kid"] = s.SigningKey.Id
+		return token.SignedString(s.SigningKey.PrivateKey)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.PrivateKey))
 }
 
-func (s *JwtService) VerifyToken(tokenString string) (int64, error) {
+func (s *JwtService) VerifyToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.PrivateKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.verificationKey)
 
 	if err != nil || !token.Valid {
-		return 0, err
+		return nil, err
+	}
+
+	if s.Sessions != nil {
+		active, err := s.Sessions.IsSessionActive(claims.SessionId())
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrSessionRevoked
+		}
 	}
 
-	return claims.UserId, nil
+	return claims, nil
+}
+
+func (s *JwtService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if s.PrivateKey == "" {
+			return nil, ErrUnknownKey
+		}
+		return []byte(s.PrivateKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	for _, key := range s.keys() {
+		if key.Id == kid && key.Method.Alg() == token.Method.Alg() {
+			return key.PublicKey, nil
+		}
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func (s *JwtService) keys() []*Key {
+	if s.SigningKey == nil {
+		return s.VerificationKeys
+	}
+	return append([]*Key{s.SigningKey}, s.VerificationKeys...)
+}
+
+// Jwks lists the public keys tokens can be verified with. The HS256 secret is
+// never published.
+func (s *JwtService) Jwks() JwkSet {
+	set := JwkSet{Keys: []Jwk{}}
+	for _, key := range s.keys() {
+		set.Keys = append(set.Keys, key.Jwk())
+	}
+	return set
 }
@@ -0,0 +1,152 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Key is an asymmetric key identified by the kid header of tokens. Keys used
+// only for verification have no private part.
+type Key struct {
+	Id         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JwkSet struct {
+	Keys []Jwk `json:"keys"`
+}
+
+// LoadKeys reads the signing key and the extra verification keys, for example
+// the previous signing key during a rotation, from PEM files. No signing key
+// file means tokens are signed with the HS256 secret.
+func LoadKeys(signingKeyFile string, verificationKeyFiles []string) (*Key, []*Key, error) {
+	var signingKey *Key
+	if signingKeyFile != "" {
+		var err error
+		signingKey, err = LoadKeyFile(signingKeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if signingKey.PrivateKey == nil {
+			return nil, nil, fmt.Errorf("%s: signing key must be a private key", signingKeyFile)
+		}
+	}
+
+	verificationKeys := []*Key{}
+	for _, file := range verificationKeyFiles {
+		key, err := LoadKeyFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return signingKey, verificationKeys, nil
+}
+
+// LoadKeyFile reads an RSA or Ed25519 key, either private or public, from a PEM
+// file. The key id is derived from the public key, so it stays the same for
+// both halves of a key pair.
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	key, err := newKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func newKey(parsed interface{}) (*Key, error) {
+	key := &Key{}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	thumbprint := sha256.Sum256(der)
+	key.Id = base64.RawURLEncoding.EncodeToString(thumbprint[:12])
+
+	return key, nil
+}
+
+func (k *Key) Jwk() Jwk {
+	jwk := Jwk{Kid: k.Id, Use: "sig", Alg: k.Method.Alg()}
+
+	switch publicKey := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+
+	return jwk
+}
@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+	Yearly  = "yearly"
+)
+
+const DateLayout = "2006-01-02"
+
+// Rule is a small subset of RFC 5545 RRULE: a frequency with an interval,
+// starting at Start and optionally ending at End (inclusive). All dates are
+// plain days at UTC midnight.
+type Rule struct {
+	Frequency string
+	Interval  int
+	Start     time.Time
+	End       *time.Time
+}
+
+func (r Rule) Validate() error {
+	switch r.Frequency {
+	case Daily, Weekly, Monthly, Yearly:
+	default:
+		return errors.New("frequency must be daily, weekly, monthly or yearly")
+	}
+
+	if r.Interval < 1 {
+		return errors.New("interval must be positive")
+	}
+
+	if r.End != nil && r.End.Before(r.Start) {
+		return errors.New("end date must not be before start date")
+	}
+
+	return nil
+}
+
+// Occurrence returns the n-th occurrence counting from zero. Monthly and
+// yearly rules stick to the day of the start date and fall back to the last
+// day of shorter months, so a rule starting on Jan 31 fires on Feb 28.
+func (r Rule) Occurrence(n int) time.Time {
+	switch r.Frequency {
+	case Daily:
+		return r.Start.AddDate(0, 0, n*r.Interval)
+	case Weekly:
+		return r.Start.AddDate(0, 0, 7*n*r.Interval)
+	case Monthly:
+		return addMonthsClamped(r.Start, n*r.Interval)
+	default:
+		return addMonthsClamped(r.Start, 12*n*r.Interval)
+	}
+}
+
+// Index returns the number of the first occurrence on or after date.
+func (r Rule) Index(date time.Time) int {
+	var n int
+	switch r.Frequency {
+	case Daily:
+		n = int(date.Sub(r.Start).Hours()/24) / r.Interval
+	case Weekly:
+		n = int(date.Sub(r.Start).Hours()/24) / (7 * r.Interval)
+	case Monthly:
+		n = monthsBetween(r.Start, date) / r.Interval
+	default:
+		n = monthsBetween(r.Start, date) / (12 * r.Interval)
+	}
+
+	// The estimate is off by one at most, around clamped month ends.
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && !r.Occurrence(n-1).Before(date) {
+		n--
+	}
+	for r.Occurrence(n).Before(date) {
+		n++
+	}
+	return n
+}
+
+// Between returns occurrences within [from, to], at most limit of them.
+func (r Rule) Between(from time.Time, to time.Time, limit int) []time.Time {
+	dates := []time.Time{}
+
+	for n := 0; len(dates) < limit; n++ {
+		date := r.Occurrence(n)
+		if date.After(to) || (r.End != nil && date.After(*r.End)) {
+			break
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+
+	return dates
+}
+
+// Has tells whether date is one of the rule occurrences.
+func (r Rule) Has(date time.Time) bool {
+	return len(r.Between(date, date, 1)) == 1
+}
+
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse(DateLayout, value)
+}
+
+func Today(now time.Time) time.Time {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func monthsBetween(from time.Time, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+func addMonthsClamped(date time.Time, months int) time.Time {
+	year, month, day := date.Date()
+	firstOfMonth := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// NewRule builds a rule out of YYYY-MM-DD dates and validates it.
+func NewRule(frequency string, interval int, startDate string, endDate *string) (Rule, error) {
+	start, err := ParseDate(startDate)
+	if err != nil {
+		return Rule{}, errors.New("invalid start date, expected YYYY-MM-DD")
+	}
+
+	rule := Rule{Frequency: frequency, Interval: interval, Start: start}
+	if endDate != nil {
+		end, err := ParseDate(*endDate)
+		if err != nil {
+			return Rule{}, errors.New("invalid end date, expected YYYY-MM-DD")
+		}
+		rule.End = &end
+	}
+
+	return rule, rule.Validate()
+}
@@ -0,0 +1,185 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := ParseDate(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestOccurrence(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		interval  int
+		start     string
+		n         int
+		want      string
+	}{
+		{"daily", Daily, 1, "2024-01-30", 3, "2024-02-02"},
+		{"daily interval", Daily, 3, "2024-01-01", 2, "2024-01-07"},
+		{"weekly", Weekly, 1, "2024-01-01", 1, "2024-01-08"},
+		{"weekly interval", Weekly, 2, "2024-01-01", 2, "2024-01-29"},
+		{"monthly", Monthly, 1, "2024-01-15", 1, "2024-02-15"},
+		{"monthly end of month in leap year", Monthly, 1, "2024-01-31", 1, "2024-02-29"},
+		{"monthly end of month", Monthly, 1, "2023-01-31", 1, "2023-02-28"},
+		{"monthly back to long month", Monthly, 1, "2023-01-31", 2, "2023-03-31"},
+		{"monthly interval", Monthly, 3, "2023-11-30", 1, "2024-02-29"},
+		{"monthly across years", Monthly, 5, "2023-10-31", 2, "2024-08-31"},
+		{"yearly", Yearly, 1, "2023-06-01", 1, "2024-06-01"},
+		{"yearly leap day", Yearly, 1, "2024-02-29", 1, "2025-02-28"},
+		{"yearly interval", Yearly, 4, "2024-02-29", 1, "2028-02-29"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := Rule{Frequency: tt.frequency, Interval: tt.interval, Start: date(t, tt.start)}
+			if got := rule.Occurrence(tt.n).Format(DateLayout); got != tt.want {
+				t.Errorf("Occurrence(%d) = %s, want %s", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency string
+		interval  int
+		start     string
+		date      string
+		want      int
+	}{
+		{"before start", Daily, 1, "2024-01-10", "2024-01-01", 0},
+		{"on start", Daily, 1, "2024-01-10", "2024-01-10", 0},
+		{"daily on occurrence", Daily, 2, "2024-01-01", "2024-01-05", 2},
+		{"daily between occurrences", Daily, 2, "2024-01-01", "2024-01-04", 2},
+		{"weekly between occurrences", Weekly, 1, "2024-01-01", "2024-01-09", 2},
+		{"monthly clamped occurrence", Monthly, 1, "2024-01-31", "2024-02-29", 1},
+		{"monthly after clamped occurrence", Monthly, 1, "2024-01-31", "2024-03-01", 2},
+		{"monthly day before occurrence", Monthly, 1, "2024-01-31", "2024-03-30", 2},
+		{"monthly interval", Monthly, 2, "2024-01-15", "2024-03-16", 2},
+		{"yearly", Yearly, 1, "2020-02-29", "2021-03-01", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := Rule{Frequency: tt.frequency, Interval: tt.interval, Start: date(t, tt.start)}
+			got := rule.Index(date(t, tt.date))
+			if got != tt.want {
+				t.Fatalf("Index(%s) = %d, want %d", tt.date, got, tt.want)
+			}
+			if rule.Occurrence(got).Before(date(t, tt.date)) {
+				t.Errorf("occurrence %d is before %s", got, tt.date)
+			}
+			if got > 0 && !rule.Occurrence(got-1).Before(date(t, tt.date)) {
+				t.Errorf("occurrence %d is not before %s", got-1, tt.date)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	end := "2024-04-30"
+	tests := []struct {
+		name     string
+		interval int
+		start    string
+		end      *string
+		from     string
+		to       string
+		limit    int
+		want     []string
+	}{
+		{"all", 1, "2024-01-31", nil, "2024-01-01", "2024-04-30", 10, []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}},
+		{"from excludes earlier", 1, "2024-01-31", nil, "2024-03-01", "2024-04-30", 10, []string{"2024-03-31", "2024-04-30"}},
+		{"limit", 1, "2024-01-31", nil, "2024-01-01", "2024-12-31", 2, []string{"2024-01-31", "2024-02-29"}},
+		{"interval", 2, "2024-01-31", nil, "2024-01-01", "2024-12-31", 3, []string{"2024-01-31", "2024-03-31", "2024-05-31"}},
+		{"end date is inclusive", 1, "2024-01-31", &end, "2024-01-01", "2024-12-31", 10, []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}},
+		{"nothing in range", 1, "2024-01-31", nil, "2024-02-01", "2024-02-28", 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := NewRule(Monthly, tt.interval, tt.start, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for _, occurrence := range rule.Between(date(t, tt.from), date(t, tt.to), tt.limit) {
+				got = append(got, occurrence.Format(DateLayout))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Between = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Between = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHas(t *testing.T) {
+	end := "2024-03-31"
+	rule, err := NewRule(Monthly, 1, "2024-01-31", &end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		date string
+		want bool
+	}{
+		{"2024-01-31", true},
+		{"2024-02-29", true},
+		{"2024-02-28", false},
+		{"2024-03-31", true},
+		{"2024-04-30", false},
+		{"2023-12-31", false},
+	}
+
+	for _, tt := range tests {
+		if got := rule.Has(date(t, tt.date)); got != tt.want {
+			t.Errorf("Has(%s) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestNewRule(t *testing.T) {
+	before := "2023-12-31"
+	invalid := "2024-13-01"
+	tests := []struct {
+		name      string
+		frequency string
+		interval  int
+		start     string
+		end       *string
+		wantErr   bool
+	}{
+		{"valid", Weekly, 1, "2024-01-01", nil, false},
+		{"unknown frequency", "hourly", 1, "2024-01-01", nil, true},
+		{"zero interval", Daily, 0, "2024-01-01", nil, true},
+		{"invalid start", Daily, 1, "01/01/2024", nil, true},
+		{"invalid end", Daily, 1, "2024-01-01", &invalid, true},
+		{"end before start", Daily, 1, "2024-01-01", &before, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRule(tt.frequency, tt.interval, tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRule error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package split
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+const (
+	Equal   = "equal"
+	Exact   = "exact"
+	Percent = "percent"
+)
+
+// Part is the share of one user. Amount is read in exact mode and Percent in
+// percent mode, equal mode reads neither.
+type Part struct {
+	UserId  int64
+	Amount  int64
+	Percent float64
+}
+
+func IsValidMode(mode string) bool {
+	return mode == Equal || mode == Exact || mode == Percent
+}
+
+// Amounts divides total between parts and returns the amount of every part in
+// the same order. Cents left over by equal and percent splits go to the first
+// parts with a share, so the amounts always add up to total and a 0% part
+// stays at zero.
+func Amounts(total int64, mode string, parts []Part) ([]int64, error) {
+	if total <= 0 {
+		return nil, errors.New("only positive amounts can be split")
+	}
+	if len(parts) == 0 {
+		return nil, errors.New("split needs at least one part")
+	}
+
+	seen := map[int64]bool{}
+	for _, part := range parts {
+		if seen[part.UserId] {
+			return nil, errors.New("every user can have only one part")
+		}
+		seen[part.UserId] = true
+	}
+
+	amounts := make([]int64, len(parts))
+	// Indexes of the parts that take the cents left over.
+	shared := []int{}
+	switch mode {
+	case Equal:
+		for i := range parts {
+			amounts[i] = total / int64(len(parts))
+			shared = append(shared, i)
+		}
+	case Exact:
+		var sum int64
+		for i, part := range parts {
+			if part.Amount < 0 {
+				return nil, errors.New("amounts must not be negative")
+			}
+			amounts[i] = part.Amount
+			sum += part.Amount
+		}
+		if sum != total {
+			return nil, errors.New("amounts must add up to the price")
+		}
+		return amounts, nil
+	case Percent:
+		// Percentages are kept in hundredths to stay exact.
+		var sum int64
+		for i, part := range parts {
+			hundredths := int64(math.Round(part.Percent * 100))
+			if hundredths < 0 {
+				return nil, errors.New("percentages must not be negative")
+			}
+			amounts[i] = total * hundredths / 10000
+			sum += hundredths
+			if hundredths > 0 {
+				shared = append(shared, i)
+			}
+		}
+		if sum != 10000 {
+			return nil, errors.New("percentages must add up to 100")
+		}
+	default:
+		return nil, errors.New("mode must be equal, exact or percent")
+	}
+
+	var sum int64
+	for _, amount := range amounts {
+		sum += amount
+	}
+	for i := 0; sum < total; i = (i + 1) % len(shared) {
+		amounts[shared[i]]++
+		sum++
+	}
+
+	return amounts, nil
+}
+
+type Debt struct {
+	From   int64
+	To     int64
+	Amount int64
+}
+
+// Simplify turns net balances, positive for users who are owed money, into a
+// short list of payments that settles all of them. Debtors and creditors are
+// matched from the biggest balances down, so every payment clears at least
+// one of them and there are fewer payments than users with a balance.
+func Simplify(balances map[int64]int64) []Debt {
+	type balance struct {
+		userId int64
+		amount int64
+	}
+
+	var creditors, debtors []balance
+	for userId, amount := range balances {
+		if amount > 0 {
+			creditors = append(creditors, balance{userId, amount})
+		} else if amount < 0 {
+			debtors = append(debtors, balance{userId, -amount})
+		}
+	}
+
+	byAmount := func(items []balance) func(i, j int) bool {
+		return func(i, j int) bool {
+			if items[i].amount != items[j].amount {
+				return items[i].amount > items[j].amount
+			}
+			return items[i].userId < items[j].userId
+		}
+	}
+	sort.Slice(creditors, byAmount(creditors))
+	sort.Slice(debtors, byAmount(debtors))
+
+	debts := []Debt{}
+	for i, j := 0, 0; i < len(debtors) && j < len(creditors); {
+		amount := min(debtors[i].amount, creditors[j].amount)
+		debts = append(debts, Debt{From: debtors[i].userId, To: creditors[j].userId, Amount: amount})
+
+		debtors[i].amount -= amount
+		creditors[j].amount -= amount
+		if debtors[i].amount == 0 {
+			i++
+		}
+		if creditors[j].amount == 0 {
+			j++
+		}
+	}
+
+	return debts
+}
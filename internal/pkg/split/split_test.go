@@ -0,0 +1,94 @@
+package split
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAmounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		mode    string
+		parts   []Part
+		want    []int64
+		wantErr bool
+	}{
+		{"equal", 90, Equal, []Part{{UserId: 1}, {UserId: 2}, {UserId: 3}}, []int64{30, 30, 30}, false},
+		{"equal leftover goes to first parts", 101, Equal, []Part{{UserId: 1}, {UserId: 2}, {UserId: 3}}, []int64{34, 34, 33}, false},
+		{"exact", 100, Exact, []Part{{UserId: 1, Amount: 70}, {UserId: 2, Amount: 30}}, []int64{70, 30}, false},
+		{"exact with zero part", 100, Exact, []Part{{UserId: 1, Amount: 100}, {UserId: 2}}, []int64{100, 0}, false},
+		{"exact not adding up", 100, Exact, []Part{{UserId: 1, Amount: 70}, {UserId: 2, Amount: 20}}, nil, true},
+		{"exact negative", 100, Exact, []Part{{UserId: 1, Amount: 110}, {UserId: 2, Amount: -10}}, nil, true},
+		{"percent", 200, Percent, []Part{{UserId: 1, Percent: 75}, {UserId: 2, Percent: 25}}, []int64{150, 50}, false},
+		{"percent fractions", 100, Percent, []Part{{UserId: 1, Percent: 33.33}, {UserId: 2, Percent: 33.33}, {UserId: 3, Percent: 33.34}}, []int64{34, 33, 33}, false},
+		{"percent leftover skips zero part", 101, Percent, []Part{{UserId: 1, Percent: 0}, {UserId: 2, Percent: 50}, {UserId: 3, Percent: 50}}, []int64{0, 51, 50}, false},
+		{"percent not adding up", 100, Percent, []Part{{UserId: 1, Percent: 50}, {UserId: 2, Percent: 40}}, nil, true},
+		{"percent negative", 100, Percent, []Part{{UserId: 1, Percent: 110}, {UserId: 2, Percent: -10}}, nil, true},
+		{"zero total", 0, Equal, []Part{{UserId: 1}}, nil, true},
+		{"no parts", 100, Equal, nil, nil, true},
+		{"duplicate user", 100, Equal, []Part{{UserId: 1}, {UserId: 1}}, nil, true},
+		{"unknown mode", 100, "shares", []Part{{UserId: 1}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Amounts(tt.total, tt.mode, tt.parts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Amounts error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Amounts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances map[int64]int64
+		want     []Debt
+	}{
+		{"settled", map[int64]int64{1: 0, 2: 0}, []Debt{}},
+		{"one debt", map[int64]int64{1: 50, 2: -50}, []Debt{{From: 2, To: 1, Amount: 50}}},
+		{
+			"one creditor",
+			map[int64]int64{1: 100, 2: -60, 3: -40},
+			[]Debt{{From: 2, To: 1, Amount: 60}, {From: 3, To: 1, Amount: 40}},
+		},
+		{
+			"biggest first",
+			map[int64]int64{1: 70, 2: 30, 3: -80, 4: -20},
+			[]Debt{{From: 3, To: 1, Amount: 70}, {From: 3, To: 2, Amount: 10}, {From: 4, To: 2, Amount: 20}},
+		},
+		{
+			"ties by user id",
+			map[int64]int64{1: 10, 2: 10, 3: -10, 4: -10},
+			[]Debt{{From: 3, To: 1, Amount: 10}, {From: 4, To: 2, Amount: 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Simplify(tt.balances)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Simplify = %v, want %v", got, tt.want)
+			}
+
+			settled := map[int64]int64{}
+			for userId, amount := range tt.balances {
+				settled[userId] = amount
+			}
+			for _, debt := range got {
+				settled[debt.From] += debt.Amount
+				settled[debt.To] -= debt.Amount
+			}
+			for userId, amount := range settled {
+				if amount != 0 {
+					t.Errorf("user %d is left with %d", userId, amount)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Storage keeps file contents by key. Keys name their content, so putting a
+// key that is already stored keeps the stored file. Lock serializes callers
+// working with one key, so a file can't be deleted as unused while someone
+// is about to start using it again.
+type Storage interface {
+	Put(key string, content io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	Lock(key string) (unlock func())
+}
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9]{4,128}$`)
+
+// LocalStorage keeps files in a directory, spread over subdirectories named
+// after the first two characters of their keys.
+type LocalStorage struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	holders int
+}
+
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir, locks: map[string]*keyLock{}}, nil
+}
+
+// Lock holds the key until unlock is called. It only guards callers within
+// this process.
+func (s *LocalStorage) Lock(key string) func() {
+	s.mu.Lock()
+	lock, ok := s.locks[key]
+	if !ok {
+		lock = &keyLock{}
+		s.locks[key] = lock
+	}
+	lock.holders++
+	s.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		s.mu.Lock()
+		lock.holders--
+		if lock.holders == 0 {
+			delete(s.locks, key)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, key[:2], key), nil
+}
+
+// Put writes the content to a temporary file first and renames it into place,
+// so readers never see a partial file.
+func (s *LocalStorage) Put(key string, content io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), key+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete removes the file, a missing one is not an error.
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
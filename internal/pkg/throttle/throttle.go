@@ -0,0 +1,139 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepThreshold is the number of tracked keys after which stale ones are
+// dropped, so the store does not grow without bounds.
+const sweepThreshold = 10000
+
+// pendingRetry is suggested to callers turned away because attempts already in
+// flight may use up the failures left.
+const pendingRetry = time.Second
+
+// Limiter counts failures per key in memory. Once a key reaches maxFailures,
+// it is locked out for baseLockout, and every further failure doubles the
+// lockout up to maxLockout. Failures are forgotten after maxLockout without
+// new ones.
+//
+// Every attempt is reserved with Acquire before it is checked and settled with
+// Fail, Release or Reset afterwards, so parallel attempts can't get past the
+// limit while earlier ones are still being checked.
+type Limiter struct {
+	mu          sync.Mutex
+	entries     map[string]*entry
+	maxFailures int
+	baseLockout time.Duration
+	maxLockout  time.Duration
+	now         func() time.Time
+}
+
+type entry struct {
+	failures    int
+	pending     int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func NewLimiter(maxFailures int, baseLockout time.Duration, maxLockout time.Duration) *Limiter {
+	return &Limiter{
+		entries:     map[string]*entry{},
+		maxFailures: maxFailures,
+		baseLockout: baseLockout,
+		maxLockout:  maxLockout,
+		now:         time.Now,
+	}
+}
+
+// Acquire reserves an attempt for the key. It returns how long the key stays
+// locked out instead when it is, or when the attempts in flight could use up
+// the failures left, and zero once the attempt is reserved.
+func (l *Limiter) Acquire(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	e := l.entry(key, now)
+
+	if remaining := e.lockedUntil.Sub(now); remaining > 0 {
+		return remaining
+	}
+	// After a lockout ran out, one attempt at a time is let through.
+	if e.pending > 0 && e.failures+e.pending >= l.maxFailures {
+		return pendingRetry
+	}
+
+	e.pending++
+	return 0
+}
+
+// Release settles a reserved attempt that succeeded without counting it.
+func (l *Limiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.entries[key]; ok && e.pending > 0 {
+		e.pending--
+	}
+}
+
+// Fail settles a reserved attempt as failed, it may lock the key out.
+func (l *Limiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	e := l.entry(key, now)
+	if e.pending > 0 {
+		e.pending--
+	}
+
+	e.failures++
+	e.lastFailure = now
+
+	if excess := e.failures - l.maxFailures; excess >= 0 {
+		lockout := l.baseLockout
+		for i := 0; i < excess && lockout < l.maxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > l.maxLockout {
+			lockout = l.maxLockout
+		}
+		e.lockedUntil = now.Add(lockout)
+	}
+}
+
+// Reset forgets the failures of the key, the attempts in flight included.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+}
+
+func (l *Limiter) entry(key string, now time.Time) *entry {
+	if len(l.entries) >= sweepThreshold {
+		l.sweep(now)
+	}
+
+	e, ok := l.entries[key]
+	if !ok || l.isStale(e, now) {
+		e = &entry{}
+		l.entries[key] = e
+	}
+	return e
+}
+
+func (l *Limiter) isStale(e *entry, now time.Time) bool {
+	return e.pending == 0 && now.After(e.lockedUntil) && now.Sub(e.lastFailure) > l.maxLockout
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, e := range l.entries {
+		if l.isStale(e, now) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter(maxFailures int) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewLimiter(maxFailures, time.Minute, 8*time.Minute)
+	l.now = func() time.Time { return c.now }
+	return l, c
+}
+
+// fail reserves an attempt and settles it as failed.
+func fail(t *testing.T, l *Limiter, key string) {
+	t.Helper()
+	if wait := l.Acquire(key); wait != 0 {
+		t.Fatalf("Acquire(%q) = %s, want 0", key, wait)
+	}
+	l.Fail(key)
+}
+
+// lockout returns how long the key is locked out without reserving an attempt.
+func lockout(l *Limiter, key string) time.Duration {
+	wait := l.Acquire(key)
+	if wait == 0 {
+		l.Release(key)
+	}
+	return wait
+}
+
+func TestLockout(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{"below the limit", 2, 0},
+		{"at the limit", 3, time.Minute},
+		{"one more doubles", 4, 2 * time.Minute},
+		{"two more double twice", 5, 4 * time.Minute},
+		{"capped at max lockout", 8, 8 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(3)
+			for i := 0; i < tt.failures; i++ {
+				fail(t, l, "key")
+				// Let every lockout run out to keep failing.
+				if i < tt.failures-1 {
+					c.advance(lockout(l, "key"))
+				}
+			}
+
+			if got := lockout(l, "key"); got != tt.want {
+				t.Errorf("lockout after %d failures = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockoutRunsOut(t *testing.T) {
+	l, c := newTestLimiter(2)
+	fail(t, l, "key")
+	fail(t, l, "key")
+
+	c.advance(30 * time.Second)
+	if got := l.Acquire("key"); got != 30*time.Second {
+		t.Fatalf("Acquire = %s, want 30s", got)
+	}
+	if got := l.Acquire("other"); got != 0 {
+		t.Fatalf("Acquire of another key = %s, want 0", got)
+	}
+
+	c.advance(30 * time.Second)
+	if got := l.Acquire("key"); got != 0 {
+		t.Fatalf("Acquire after the lockout = %s, want 0", got)
+	}
+}
+
+func TestFailuresAreForgotten(t *testing.T) {
+	l, c := newTestLimiter(2)
+	fail(t, l, "key")
+
+	c.advance(8*time.Minute + time.Second)
+	fail(t, l, "key")
+	if got := l.Acquire("key"); got != 0 {
+		t.Errorf("Acquire = %s, want the old failure forgotten", got)
+	}
+}
+
+func TestPendingAttempts(t *testing.T) {
+	l, _ := newTestLimiter(3)
+
+	for i := 0; i < 3; i++ {
+		if got := l.Acquire("key"); got != 0 {
+			t.Fatalf("Acquire %d = %s, want 0", i, got)
+		}
+	}
+	if got := l.Acquire("key"); got != pendingRetry {
+		t.Fatalf("Acquire with all attempts in flight = %s, want %s", got, pendingRetry)
+	}
+
+	l.Release("key")
+	if got := l.Acquire("key"); got != 0 {
+		t.Fatalf("Acquire after Release = %s, want 0", got)
+	}
+
+	l.Fail("key")
+	l.Fail("key")
+	l.Fail("key")
+	if got := l.Acquire("key"); got != time.Minute {
+		t.Fatalf("Acquire after the attempts failed = %s, want 1m", got)
+	}
+}
+
+func TestOneAttemptAfterLockout(t *testing.T) {
+	l, c := newTestLimiter(2)
+	fail(t, l, "key")
+	fail(t, l, "key")
+	c.advance(time.Minute)
+
+	if got := l.Acquire("key"); got != 0 {
+		t.Fatalf("Acquire = %s, want 0", got)
+	}
+	if got := l.Acquire("key"); got != pendingRetry {
+		t.Fatalf("second Acquire = %s, want %s", got, pendingRetry)
+	}
+}
+
+func TestReset(t *testing.T) {
+	l, _ := newTestLimiter(2)
+	fail(t, l, "key")
+	fail(t, l, "key")
+
+	l.Reset("key")
+	if got := l.Acquire("key"); got != 0 {
+		t.Errorf("Acquire after Reset = %s, want 0", got)
+	}
+}
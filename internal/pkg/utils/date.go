@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ParseDate accepts either an RFC3339 timestamp or a plain YYYY-MM-DD date.
+func ParseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// ParseDateRangeEnd works like ParseDate, but treats a plain date as the whole
+// day and returns the start of the next one, so it can be used as an
+// exclusive upper bound.
+func ParseDateRangeEnd(value string) (time.Time, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date.AddDate(0, 0, 1), nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,148 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const contentTypesXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const rootRelsXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbookRelsXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+const workbookXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const sheetHeaderXml = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetFooterXml = `</sheetData></worksheet>`
+
+// Writer streams a single-sheet workbook. Rows are written straight into the
+// zip entry, so memory usage does not depend on the number of rows.
+type Writer struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	escapedName, err := escape(sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXml},
+		{"_rels/.rels", rootRelsXml},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXml, escapedName)},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXml},
+	}
+
+	for _, part := range parts {
+		partWriter, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(partWriter, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, sheetHeaderXml); err != nil {
+		return nil, err
+	}
+
+	return &Writer{zip: zw, sheet: sheet}, nil
+}
+
+// WriteRow appends a row to the sheet. Integers and floats become numeric
+// cells, everything else is written as text.
+func (w *Writer) WriteRow(cells []interface{}) error {
+	w.row++
+	rowNumber := strconv.Itoa(w.row)
+
+	if _, err := io.WriteString(w.sheet, `<row r="`+rowNumber+`">`); err != nil {
+		return err
+	}
+
+	for i, cell := range cells {
+		ref := columnName(i) + rowNumber
+
+		var cellXml string
+		switch value := cell.(type) {
+		case int:
+			cellXml = `<c r="` + ref + `"><v>` + strconv.Itoa(value) + `</v></c>`
+		case int64:
+			cellXml = `<c r="` + ref + `"><v>` + strconv.FormatInt(value, 10) + `</v></c>`
+		case float64:
+			cellXml = `<c r="` + ref + `"><v>` + strconv.FormatFloat(value, 'f', -1, 64) + `</v></c>`
+		default:
+			text, err := escape(fmt.Sprint(value))
+			if err != nil {
+				return err
+			}
+			cellXml = `<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + text + `</t></is></c>`
+		}
+
+		if _, err := io.WriteString(w.sheet, cellXml); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w.sheet, `</row>`)
+	return err
+}
+
+func (w *Writer) Close() error {
+	if _, err := io.WriteString(w.sheet, sheetFooterXml); err != nil {
+		return err
+	}
+
+	return w.zip.Close()
+}
+
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func escape(value string) (string, error) {
+	var builder strings.Builder
+	if err := xml.EscapeText(&builder, []byte(value)); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
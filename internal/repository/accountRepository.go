@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+)
+
+// signedPriceSql is the effect of a "Transactions" row on its account balance.
+// Transfers are already stored with the sign of the direction of the money.
+const signedPriceSql = `(CASE "Transactions"."Kind"
+    WHEN 'income' THEN "Transactions"."Price"
+    WHEN 'expense' THEN -"Transactions"."Price"
+    ELSE "Transactions"."Price" END)`
+
+type AccountRepository interface {
+	WithTx(tx *sql.Tx) AccountRepository
+	CreateAccount(account model.Account) (int64, error)
+	GetAccountById(id int64) (model.Account, error)
+	GetAccounts(userId int64) ([]model.Account, error)
+	GetAccountStatement(accountId int64, pagination SqlPagination) (PaginationResponse[model.AccountStatementEntry], error)
+	UpdateAccount(account model.Account) error
+	DeleteAccount(id int64, userId int64) error
+	HasTransactions(id int64) (bool, error)
+}
+
+type accountRepository struct {
+	db dbtx
+}
+
+func GetAccountRepository(db *sql.DB) *accountRepository {
+	return &accountRepository{db: db}
+}
+
+func (repo *accountRepository) WithTx(tx *sql.Tx) AccountRepository {
+	return &accountRepository{db: tx}
+}
+
+const accountColumns = `"Accounts"."Id", "Accounts"."UserId", "Accounts"."Name", "Accounts"."Type", "Accounts"."Currency", "Accounts"."OpeningBalance",
+    "Accounts"."OpeningBalance" + COALESCE((SELECT SUM(` + signedPriceSql + `) FROM "Transactions" WHERE "Transactions"."AccountId" = "Accounts"."Id" AND "Transactions"."DeletedAt" IS NULL), 0),
+    "Accounts"."CreatedAt"`
+
+func scanAccount(scanner rowScanner) (model.Account, error) {
+	var account model.Account
+	err := scanner.Scan(&account.Id, &account.UserId, &account.Name, &account.Type, &account.Currency, &account.OpeningBalance, &account.Balance, &account.CreatedAt)
+	return account, err
+}
+
+func (repo *accountRepository) CreateAccount(account model.Account) (int64, error) {
+	query := `INSERT INTO "Accounts" ("UserId", "Name", "Type", "Currency", "OpeningBalance") VALUES ($1, $2, $3, $4, $5)`
+	result, err := repo.db.Exec(query, account.UserId, account.Name, account.Type, account.Currency, account.OpeningBalance)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (repo *accountRepository) GetAccountById(id int64) (model.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM "Accounts" WHERE "Accounts"."Id" = $1 LIMIT 1`
+	return scanAccount(repo.db.QueryRow(query, id))
+}
+
+func (repo *accountRepository) GetAccounts(userId int64) ([]model.Account, error) {
+	accounts := []model.Account{}
+	query := `SELECT ` + accountColumns + ` FROM "Accounts" WHERE "Accounts"."UserId" = $1 ORDER BY "Accounts"."Id"`
+	rows, err := repo.db.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// GetAccountStatement lists account transactions from the newest one, each with
+// the balance right after it.
+func (repo *accountRepository) GetAccountStatement(accountId int64, pagination SqlPagination) (PaginationResponse[model.AccountStatementEntry], error) {
+	entries := []model.AccountStatementEntry{}
+
+	var totalCount int64
+	countQuery := `SELECT COUNT(*) FROM "Transactions" WHERE "AccountId" = $1 AND "DeletedAt" IS NULL`
+	if err := repo.db.QueryRow(countQuery, accountId).Scan(&totalCount); err != nil {
+		return PaginationResponse[model.AccountStatementEntry]{Items: entries, Count: 0}, err
+	}
+
+	query := `
+        SELECT "Id", "Kind", "Price", "Date", "Description", "Balance" FROM (
+            SELECT "Transactions"."Id", "Transactions"."Kind", "Transactions"."Price", "Transactions"."Date", "Transactions"."Description",
+                "Accounts"."OpeningBalance" + SUM(` + signedPriceSql + `) OVER (ORDER BY "Transactions"."Date", "Transactions"."Id") AS "Balance"
+            FROM "Transactions"
+            INNER JOIN "Accounts" ON "Accounts"."Id" = "Transactions"."AccountId"
+            WHERE "Transactions"."AccountId" = $1 AND "Transactions"."DeletedAt" IS NULL
+        ) AS statement
+        ORDER BY "Date" DESC, "Id" DESC
+        LIMIT $2 OFFSET $3`
+	rows, err := repo.db.Query(query, accountId, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return PaginationResponse[model.AccountStatementEntry]{Items: entries, Count: 0}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.AccountStatementEntry
+		if err := rows.Scan(&entry.TransactionId, &entry.Kind, &entry.Price, &entry.Date, &entry.Description, &entry.Balance); err != nil {
+			return PaginationResponse[model.AccountStatementEntry]{Items: entries, Count: 0}, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return PaginationResponse[model.AccountStatementEntry]{Items: entries, Count: totalCount}, nil
+}
+
+func (repo *accountRepository) UpdateAccount(account model.Account) error {
+	query := `UPDATE "Accounts" SET "Name" = $1, "Type" = $2, "OpeningBalance" = $3 WHERE "Id" = $4`
+	_, err := repo.db.Exec(query, account.Name, account.Type, account.OpeningBalance, account.Id)
+	return err
+}
+
+// DeleteAccount deletes the account, detaching the transactions in the trash
+// from it on behalf of userId. Live transactions have to be gone first, see
+// HasTransactions. It has to run inside a transaction, see WithTx.
+func (repo *accountRepository) DeleteAccount(id int64, userId int64) error {
+	query := `
+        INSERT INTO "TransactionHistory" ("TransactionId", "LedgerId", "UserId", "Action", "Changes")
+        SELECT "Id", "LedgerId", $1, $2, json_object('accountId', json_object('old', "AccountId", 'new', NULL))
+        FROM "Transactions" WHERE "AccountId" = $3 AND "DeletedAt" IS NOT NULL`
+	if _, err := repo.db.Exec(query, userId, model.TransactionActionUpdate, id); err != nil {
+		return err
+	}
+
+	if _, err := repo.db.Exec(`UPDATE "Transactions" SET "AccountId" = NULL WHERE "AccountId" = $1 AND "DeletedAt" IS NOT NULL`, id); err != nil {
+		return err
+	}
+
+	_, err := repo.db.Exec(`DELETE FROM "Accounts" WHERE "Id" = $1`, id)
+	return err
+}
+
+// HasTransactions tells whether any transaction or recurring transaction
+// still uses the account. Transactions in the trash don't count.
+func (repo *accountRepository) HasTransactions(id int64) (bool, error) {
+	var exists bool
+	query := `
+        SELECT EXISTS (SELECT 1 FROM "Transactions" WHERE "AccountId" = $1 AND "DeletedAt" IS NULL)
+            OR EXISTS (SELECT 1 FROM "RecurringTransactions" WHERE "AccountId" = $1)`
+	err := repo.db.QueryRow(query, id).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"time"
+)
+
+type AttachmentRepository interface {
+	WithTx(tx *sql.Tx) AttachmentRepository
+	CreateAttachment(attachment model.Attachment) (int64, error)
+	GetAttachmentById(id int64) (model.Attachment, error)
+	GetAttachments(transactionId int64) ([]model.Attachment, error)
+	DeleteAttachment(id int64) error
+	GetUserUsage(userId int64) (int64, error)
+	HasUserHash(userId int64, hash string) (bool, error)
+	IsHashUsed(hash string) (bool, error)
+	PurgeDeletedTransactionAttachments(before time.Time) ([]string, error)
+}
+
+type attachmentRepository struct {
+	db dbtx
+}
+
+func GetAttachmentRepository(db *sql.DB) *attachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (repo *attachmentRepository) WithTx(tx *sql.Tx) AttachmentRepository {
+	return &attachmentRepository{db: tx}
+}
+
+func (repo *attachmentRepository) CreateAttachment(attachment model.Attachment) (int64, error) {
+	query := `
+        INSERT INTO "Attachments" ("TransactionId", "UserId", "FileName", "ContentType", "Size", "Hash")
+        VALUES ($1, $2, $3, $4, $5, $6)`
+	result, err := repo.db.Exec(query, attachment.TransactionId, attachment.UserId, attachment.FileName, attachment.ContentType, attachment.Size, attachment.Hash)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func scanAttachment(scanner rowScanner) (model.Attachment, error) {
+	var attachment model.Attachment
+	err := scanner.Scan(&attachment.Id, &attachment.TransactionId, &attachment.UserId, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.Hash, &attachment.CreatedAt)
+	return attachment, err
+}
+
+func (repo *attachmentRepository) GetAttachmentById(id int64) (model.Attachment, error) {
+	query := `SELECT "Id", "TransactionId", "UserId", "FileName", "ContentType", "Size", "Hash", "CreatedAt" FROM "Attachments" WHERE "Id" = $1 LIMIT 1`
+	return scanAttachment(repo.db.QueryRow(query, id))
+}
+
+func (repo *attachmentRepository) GetAttachments(transactionId int64) ([]model.Attachment, error) {
+	attachments := []model.Attachment{}
+	query := `
+        SELECT "Id", "TransactionId", "UserId", "FileName", "ContentType", "Size", "Hash", "CreatedAt" FROM "Attachments"
+        WHERE "TransactionId" = $1 ORDER BY "Id"`
+	rows, err := repo.db.Query(query, transactionId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (repo *attachmentRepository) DeleteAttachment(id int64) error {
+	_, err := repo.db.Exec(`DELETE FROM "Attachments" WHERE "Id" = $1`, id)
+	return err
+}
+
+// GetUserUsage sums the sizes of the files the user uploaded, counting every
+// distinct file once.
+func (repo *attachmentRepository) GetUserUsage(userId int64) (int64, error) {
+	var usage int64
+	query := `SELECT COALESCE(SUM("Size"), 0) FROM (SELECT DISTINCT "Hash", "Size" FROM "Attachments" WHERE "UserId" = $1)`
+	err := repo.db.QueryRow(query, userId).Scan(&usage)
+	return usage, err
+}
+
+// HasUserHash tells whether the user already uploaded a file with the hash,
+// which then doesn't count against their quota again.
+func (repo *attachmentRepository) HasUserHash(userId int64, hash string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM "Attachments" WHERE "UserId" = $1 AND "Hash" = $2)`
+	err := repo.db.QueryRow(query, userId, hash).Scan(&exists)
+	return exists, err
+}
+
+// IsHashUsed tells whether any attachment still needs the stored file.
+func (repo *attachmentRepository) IsHashUsed(hash string) (bool, error) {
+	var used bool
+	query := `SELECT EXISTS (SELECT 1 FROM "Attachments" WHERE "Hash" = $1)`
+	err := repo.db.QueryRow(query, hash).Scan(&used)
+	return used, err
+}
+
+// PurgeDeletedTransactionAttachments deletes the attachments of transactions
+// that the trash purge is about to remove, see PurgeDeletedTransactions and
+// PurgeDeletedCategories. It returns the hashes of their files, which have to
+// be deleted from the storage once nothing uses them.
+func (repo *attachmentRepository) PurgeDeletedTransactionAttachments(before time.Time) ([]string, error) {
+	purged := `
+        "TransactionId" IN (
+            SELECT "Id" FROM "Transactions"
+            WHERE "DeletedAt" < $1 OR "CategoryId" IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" < $1)
+        )`
+
+	rows, err := repo.db.Query(`SELECT DISTINCT "Hash" FROM "Attachments" WHERE `+purged, formatSqlTime(before))
+	if err != nil {
+		return nil, err
+	}
+	hashes := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := repo.db.Exec(`DELETE FROM "Attachments" WHERE `+purged, formatSqlTime(before)); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+)
+
+type BudgetRepository interface {
+	CreateBudget(budget model.Budget) (int64, error)
+	GetBudgetById(id int64) (model.Budget, error)
+	GetBudgetForPeriod(userId int64, categoryId int64, year int, month int) (model.Budget, error)
+	GetBudgets(userId int64, year int, month int) ([]model.Budget, error)
+	UpdateBudget(budget model.Budget) error
+	DeleteBudget(id int64) error
+}
+
+type budgetRepository struct {
+	db dbtx
+}
+
+// liveCategorySql hides budgets of categories in the trash, they come back
+// when the category is restored.
+const liveCategorySql = `"CategoryId" NOT IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL)`
+
+func GetBudgetRepository(db *sql.DB) *budgetRepository {
+	return &budgetRepository{db: db}
+}
+
+func (repo *budgetRepository) CreateBudget(budget model.Budget) (int64, error) {
+	query := `INSERT INTO "Budgets" ("UserId", "CategoryId", "Year", "Month", "Amount", "Rollover") VALUES ($1, $2, $3, $4, $5, $6)`
+	result, err := repo.db.Exec(query, budget.UserId, budget.CategoryId, budget.Year, budget.Month, budget.Amount, budget.Rollover)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (repo *budgetRepository) GetBudgetById(id int64) (model.Budget, error) {
+	var budget model.Budget
+	query := `SELECT "Id", "UserId", "CategoryId", "Year", "Month", "Amount", "Rollover" FROM "Budgets" WHERE "Id" = $1 AND ` + liveCategorySql + ` LIMIT 1`
+	err := repo.db.QueryRow(query, id).Scan(&budget.Id, &budget.UserId, &budget.CategoryId, &budget.Year, &budget.Month, &budget.Amount, &budget.Rollover)
+	return budget, err
+}
+
+func (repo *budgetRepository) GetBudgetForPeriod(userId int64, categoryId int64, year int, month int) (model.Budget, error) {
+	var budget model.Budget
+	query := `
+        SELECT "Id", "UserId", "CategoryId", "Year", "Month", "Amount", "Rollover" FROM "Budgets"
+        WHERE "UserId" = $1 AND "CategoryId" = $2 AND "Year" = $3 AND "Month" = $4 AND ` + liveCategorySql + ` LIMIT 1`
+	err := repo.db.QueryRow(query, userId, categoryId, year, month).Scan(&budget.Id, &budget.UserId, &budget.CategoryId, &budget.Year, &budget.Month, &budget.Amount, &budget.Rollover)
+	return budget, err
+}
+
+func (repo *budgetRepository) GetBudgets(userId int64, year int, month int) ([]model.Budget, error) {
+	budgets := []model.Budget{}
+	query := `
+        SELECT "Id", "UserId", "CategoryId", "Year", "Month", "Amount", "Rollover" FROM "Budgets"
+        WHERE "UserId" = $1 AND "Year" = $2 AND "Month" = $3 AND ` + liveCategorySql + `
+        ORDER BY "CategoryId"`
+	rows, err := repo.db.Query(query, userId, year, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var budget model.Budget
+		if err := rows.Scan(&budget.Id, &budget.UserId, &budget.CategoryId, &budget.Year, &budget.Month, &budget.Amount, &budget.Rollover); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, nil
+}
+
+func (repo *budgetRepository) UpdateBudget(budget model.Budget) error {
+	query := `UPDATE "Budgets" SET "Amount" = $1, "Rollover" = $2 WHERE "Id" = $3`
+	_, err := repo.db.Exec(query, budget.Amount, budget.Rollover, budget.Id)
+	return err
+}
+
+func (repo *budgetRepository) DeleteBudget(id int64) error {
+	query := `DELETE FROM "Budgets" WHERE "Id" = $1`
+	_, err := repo.db.Exec(query, id)
+	return err
+}
@@ -2,10 +2,66 @@ package repository
 
 import (
 	"database/sql"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const sqlTimeLayout = "2006-01-02 15:04:05"
+
+// dbtx is implemented by both *sql.DB and *sql.Tx, so repositories can run
+// either on their own or as a part of a bigger transaction.
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+type Transactor interface {
+	InTransaction(fn func(tx *sql.Tx) error) error
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+// GetSqliteDb opens the database with foreign keys enforced, which SQLite
+// leaves off unless every connection asks for it.
 func GetSqliteDb(path string) (*sql.DB, error) {
-	return sql.Open("sqlite3", path)
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return sql.Open("sqlite3", path+separator+"_foreign_keys=on")
+}
+
+func GetTransactor(db *sql.DB) *transactor {
+	return &transactor{db: db}
+}
+
+// InTransaction commits if fn succeeds and rolls everything back otherwise.
+func (t *transactor) InTransaction(fn func(tx *sql.Tx) error) error {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// formatSqlTime formats t the same way CURRENT_TIMESTAMP does, so stored
+// timestamps can be compared as plain strings.
+func formatSqlTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
 }
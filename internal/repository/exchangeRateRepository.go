@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/utils"
+	"strconv"
+)
+
+type ExchangeRateRepository interface {
+	WithTx(tx *sql.Tx) ExchangeRateRepository
+	SaveExchangeRate(rate model.ExchangeRate) error
+	GetExchangeRates(currency string, pagination SqlPagination) (PaginationResponse[model.ExchangeRate], error)
+}
+
+type exchangeRateRepository struct {
+	db dbtx
+}
+
+func GetExchangeRateRepository(db *sql.DB) *exchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+func (repo *exchangeRateRepository) WithTx(tx *sql.Tx) ExchangeRateRepository {
+	return &exchangeRateRepository{db: tx}
+}
+
+func (repo *exchangeRateRepository) SaveExchangeRate(rate model.ExchangeRate) error {
+	query := `
+        INSERT INTO "ExchangeRates" ("Date", "FromCurrency", "ToCurrency", "Rate") VALUES ($1, $2, $3, $4)
+        ON CONFLICT ("FromCurrency", "ToCurrency", "Date") DO UPDATE SET "Rate" = excluded."Rate"`
+	_, err := repo.db.Exec(query, rate.Date, rate.From, rate.To, rate.Rate)
+	return err
+}
+
+func (repo *exchangeRateRepository) GetExchangeRates(currency string, pagination SqlPagination) (PaginationResponse[model.ExchangeRate], error) {
+	rates := []model.ExchangeRate{}
+
+	counter := &utils.IncreasingCounter{}
+	query := `SELECT "Id", "Date", "FromCurrency", "ToCurrency", "Rate" FROM "ExchangeRates"`
+	queryParams := []interface{}{}
+
+	if currency != "" {
+		placeholder := "$" + strconv.Itoa(counter.Next())
+		query += ` WHERE "FromCurrency" = ` + placeholder + ` OR "ToCurrency" = ` + placeholder
+		queryParams = append(queryParams, currency)
+	}
+
+	var totalCount int64
+	if err := repo.db.QueryRow(`SELECT COUNT(*) FROM (`+query+`) AS subquery`, queryParams...).Scan(&totalCount); err != nil {
+		return PaginationResponse[model.ExchangeRate]{Items: rates, Count: 0}, err
+	}
+
+	query += ` ORDER BY "Date" DESC, "FromCurrency", "ToCurrency" LIMIT $` + strconv.Itoa(counter.Next()) + ` OFFSET $` + strconv.Itoa(counter.Next())
+	queryParams = append(queryParams, pagination.Limit, pagination.Offset)
+
+	rows, err := repo.db.Query(query, queryParams...)
+	if err != nil {
+		return PaginationResponse[model.ExchangeRate]{Items: rates, Count: 0}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate model.ExchangeRate
+		if err := rows.Scan(&rate.Id, &rate.Date, &rate.From, &rate.To, &rate.Rate); err != nil {
+			return PaginationResponse[model.ExchangeRate]{Items: rates, Count: 0}, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return PaginationResponse[model.ExchangeRate]{Items: rates, Count: totalCount}, nil
+}
+
+// convertedPriceSql returns an expression with the price of the current
+// "Transactions" row converted into the currency bound to placeholder. It uses
+// the latest rate known on the transaction date, either direct or inverse,
+// and evaluates to NULL when there is none.
+func convertedPriceSql(placeholder string) string {
+	return `(CASE WHEN "Transactions"."Currency" = ` + placeholder + ` THEN "Transactions"."Price" * 1.0
+        ELSE "Transactions"."Price" * COALESCE(
+            (SELECT "Rate" FROM "ExchangeRates"
+                WHERE "FromCurrency" = "Transactions"."Currency" AND "ToCurrency" = ` + placeholder + ` AND "Date" <= date("Transactions"."Date")
+                ORDER BY "Date" DESC LIMIT 1),
+            (SELECT 1.0 / "Rate" FROM "ExchangeRates"
+                WHERE "FromCurrency" = ` + placeholder + ` AND "ToCurrency" = "Transactions"."Currency" AND "Date" <= date("Transactions"."Date")
+                ORDER BY "Date" DESC LIMIT 1)
+        ) END)`
+}
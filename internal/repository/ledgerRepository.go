@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+)
+
+type LedgerRepository interface {
+	WithTx(tx *sql.Tx) LedgerRepository
+	CreateLedger(name string, ownerId int64) (int64, error)
+	GetLedgerById(id int64) (model.Ledger, error)
+	GetLedgers(userId int64) ([]model.Ledger, error)
+	UpdateLedger(id int64, name string) error
+	GetMemberRole(ledgerId int64, userId int64) (string, error)
+	GetMembers(ledgerId int64) ([]model.LedgerMember, error)
+	AddMember(ledgerId int64, userId int64, role string) error
+	UpdateMemberRole(ledgerId int64, userId int64, role string) error
+	RemoveMember(ledgerId int64, userId int64) error
+	CountOwners(ledgerId int64) (int64, error)
+	CreateInvitation(invitation model.LedgerInvitation, invitedBy int64) (int64, error)
+	GetInvitationById(id int64) (model.LedgerInvitation, error)
+	GetInvitations(userId int64) ([]model.LedgerInvitation, error)
+	DeleteInvitation(id int64) error
+}
+
+type ledgerRepository struct {
+	db dbtx
+}
+
+func GetLedgerRepository(db *sql.DB) *ledgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+func (repo *ledgerRepository) WithTx(tx *sql.Tx) LedgerRepository {
+	return &ledgerRepository{db: tx}
+}
+
+// CreateLedger creates a ledger with ownerId as its owner. It has to run
+// inside a transaction, see WithTx.
+func (repo *ledgerRepository) CreateLedger(name string, ownerId int64) (int64, error) {
+	result, err := repo.db.Exec(`INSERT INTO "Ledgers" ("Name") VALUES ($1)`, name)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return id, repo.AddMember(id, ownerId, model.LedgerRoleOwner)
+}
+
+func (repo *ledgerRepository) GetLedgerById(id int64) (model.Ledger, error) {
+	var ledger model.Ledger
+	query := `SELECT "Id", "Name", "CreatedAt" FROM "Ledgers" WHERE "Id" = $1 LIMIT 1`
+	err := repo.db.QueryRow(query, id).Scan(&ledger.Id, &ledger.Name, &ledger.CreatedAt)
+	return ledger, err
+}
+
+// GetLedgers lists ledgers the user is a member of, along with their role.
+func (repo *ledgerRepository) GetLedgers(userId int64) ([]model.Ledger, error) {
+	ledgers := []model.Ledger{}
+	query := `
+        SELECT "Ledgers"."Id", "Ledgers"."Name", "LedgerMembers"."Role", "Ledgers"."Id" = "Users"."DefaultLedgerId", "Ledgers"."CreatedAt"
+        FROM "LedgerMembers"
+        INNER JOIN "Ledgers" ON "Ledgers"."Id" = "LedgerMembers"."LedgerId"
+        INNER JOIN "Users" ON "Users"."Id" = "LedgerMembers"."UserId"
+        WHERE "LedgerMembers"."UserId" = $1
+        ORDER BY "Ledgers"."Id"`
+	rows, err := repo.db.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ledger model.Ledger
+		if err := rows.Scan(&ledger.Id, &ledger.Name, &ledger.Role, &ledger.IsDefault, &ledger.CreatedAt); err != nil {
+			return nil, err
+		}
+		ledgers = append(ledgers, ledger)
+	}
+
+	return ledgers, rows.Err()
+}
+
+func (repo *ledgerRepository) UpdateLedger(id int64, name string) error {
+	query := `UPDATE "Ledgers" SET "Name" = $1 WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, name, id)
+	return err
+}
+
+// GetMemberRole returns sql.ErrNoRows when the user is not a member.
+func (repo *ledgerRepository) GetMemberRole(ledgerId int64, userId int64) (string, error) {
+	var role string
+	query := `SELECT "Role" FROM "LedgerMembers" WHERE "LedgerId" = $1 AND "UserId" = $2 LIMIT 1`
+	err := repo.db.QueryRow(query, ledgerId, userId).Scan(&role)
+	return role, err
+}
+
+func (repo *ledgerRepository) GetMembers(ledgerId int64) ([]model.LedgerMember, error) {
+	members := []model.LedgerMember{}
+	query := `
+        SELECT "LedgerMembers"."LedgerId", "LedgerMembers"."UserId", "Users"."Login", "LedgerMembers"."Role", "LedgerMembers"."CreatedAt"
+        FROM "LedgerMembers"
+        INNER JOIN "Users" ON "Users"."Id" = "LedgerMembers"."UserId"
+        WHERE "LedgerMembers"."LedgerId" = $1
+        ORDER BY "LedgerMembers"."CreatedAt", "LedgerMembers"."UserId"`
+	rows, err := repo.db.Query(query, ledgerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member model.LedgerMember
+		if err := rows.Scan(&member.LedgerId, &member.UserId, &member.Login, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+func (repo *ledgerRepository) AddMember(ledgerId int64, userId int64, role string) error {
+	query := `INSERT INTO "LedgerMembers" ("LedgerId", "UserId", "Role") VALUES ($1, $2, $3)`
+	_, err := repo.db.Exec(query, ledgerId, userId, role)
+	return err
+}
+
+func (repo *ledgerRepository) UpdateMemberRole(ledgerId int64, userId int64, role string) error {
+	query := `UPDATE "LedgerMembers" SET "Role" = $1 WHERE "LedgerId" = $2 AND "UserId" = $3`
+	_, err := repo.db.Exec(query, role, ledgerId, userId)
+	return err
+}
+
+func (repo *ledgerRepository) RemoveMember(ledgerId int64, userId int64) error {
+	query := `DELETE FROM "LedgerMembers" WHERE "LedgerId" = $1 AND "UserId" = $2`
+	_, err := repo.db.Exec(query, ledgerId, userId)
+	return err
+}
+
+func (repo *ledgerRepository) CountOwners(ledgerId int64) (int64, error) {
+	var count int64
+	query := `SELECT COUNT(*) FROM "LedgerMembers" WHERE "LedgerId" = $1 AND "Role" = 'owner'`
+	err := repo.db.QueryRow(query, ledgerId).Scan(&count)
+	return count, err
+}
+
+func (repo *ledgerRepository) CreateInvitation(invitation model.LedgerInvitation, invitedBy int64) (int64, error) {
+	query := `INSERT INTO "LedgerInvitations" ("LedgerId", "UserId", "Role", "InvitedBy") VALUES ($1, $2, $3, $4)`
+	result, err := repo.db.Exec(query, invitation.LedgerId, invitation.UserId, invitation.Role, invitedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const invitationQuery = `
+    SELECT "LedgerInvitations"."Id", "LedgerInvitations"."LedgerId", "Ledgers"."Name", "LedgerInvitations"."UserId", "LedgerInvitations"."Role", "Users"."Login", "LedgerInvitations"."CreatedAt"
+    FROM "LedgerInvitations"
+    INNER JOIN "Ledgers" ON "Ledgers"."Id" = "LedgerInvitations"."LedgerId"
+    INNER JOIN "Users" ON "Users"."Id" = "LedgerInvitations"."InvitedBy"`
+
+func scanInvitation(scanner rowScanner) (model.LedgerInvitation, error) {
+	var invitation model.LedgerInvitation
+	err := scanner.Scan(&invitation.Id, &invitation.LedgerId, &invitation.LedgerName, &invitation.UserId, &invitation.Role, &invitation.InvitedBy, &invitation.CreatedAt)
+	return invitation, err
+}
+
+func (repo *ledgerRepository) GetInvitationById(id int64) (model.LedgerInvitation, error) {
+	return scanInvitation(repo.db.QueryRow(invitationQuery+` WHERE "LedgerInvitations"."Id" = $1 LIMIT 1`, id))
+}
+
+// GetInvitations lists pending invitations addressed to the user.
+func (repo *ledgerRepository) GetInvitations(userId int64) ([]model.LedgerInvitation, error) {
+	invitations := []model.LedgerInvitation{}
+	rows, err := repo.db.Query(invitationQuery+` WHERE "LedgerInvitations"."UserId" = $1 ORDER BY "LedgerInvitations"."Id"`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (repo *ledgerRepository) DeleteInvitation(id int64) error {
+	query := `DELETE FROM "LedgerInvitations" WHERE "Id" = $1`
+	_, err := repo.db.Exec(query, id)
+	return err
+}
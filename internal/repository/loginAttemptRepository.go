@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+)
+
+type LoginAttemptRepository interface {
+	CreateLoginAttempt(userId int64, ip string, userAgent string) error
+	GetLoginAttempts(userId int64, pagination SqlPagination) (PaginationResponse[model.LoginAttempt], error)
+}
+
+type loginAttemptRepository struct {
+	db dbtx
+}
+
+func GetLoginAttemptRepository(db *sql.DB) *loginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (repo *loginAttemptRepository) CreateLoginAttempt(userId int64, ip string, userAgent string) error {
+	query := `INSERT INTO "LoginAttempts" ("UserId", "Ip", "UserAgent") VALUES ($1, $2, $3)`
+	_, err := repo.db.Exec(query, userId, ip, userAgent)
+	return err
+}
+
+func (repo *loginAttemptRepository) GetLoginAttempts(userId int64, pagination SqlPagination) (PaginationResponse[model.LoginAttempt], error) {
+	attempts := []model.LoginAttempt{}
+
+	var totalCount int64
+	countQuery := `SELECT COUNT(*) FROM "LoginAttempts" WHERE "UserId" = $1`
+	if err := repo.db.QueryRow(countQuery, userId).Scan(&totalCount); err != nil {
+		return PaginationResponse[model.LoginAttempt]{Items: attempts, Count: 0}, err
+	}
+
+	query := `
+        SELECT "Id", "Ip", "UserAgent", "CreatedAt" FROM "LoginAttempts"
+        WHERE "UserId" = $1
+        ORDER BY "Id" DESC
+        LIMIT $2 OFFSET $3`
+	rows, err := repo.db.Query(query, userId, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return PaginationResponse[model.LoginAttempt]{Items: attempts, Count: 0}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt model.LoginAttempt
+		if err := rows.Scan(&attempt.Id, &attempt.Ip, &attempt.UserAgent, &attempt.CreatedAt); err != nil {
+			return PaginationResponse[model.LoginAttempt]{Items: attempts, Count: 0}, err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return PaginationResponse[model.LoginAttempt]{Items: attempts, Count: totalCount}, nil
+}
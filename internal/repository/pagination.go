@@ -8,6 +8,8 @@ type Pagination struct {
 type PaginationResponse[T any] struct {
 	Items []T   `json:"items"`
 	Count int64 `json:"count"`
+	// NextCursor is set by lists with keyset pagination while more items follow.
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type SqlPagination struct {
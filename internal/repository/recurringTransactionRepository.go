@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+)
+
+type RecurringTransactionRepository interface {
+	WithTx(tx *sql.Tx) RecurringTransactionRepository
+	CreateRecurringTransaction(recurring model.RecurringTransaction) (int64, error)
+	GetRecurringTransactionById(id int64) (model.RecurringTransaction, error)
+	GetRecurringTransactions(userId int64) ([]model.RecurringTransaction, error)
+	GetStartedRecurringTransactions(date string) ([]model.RecurringTransaction, error)
+	UpdateRecurringTransaction(recurring model.RecurringTransaction) error
+	DeleteRecurringTransaction(id int64) error
+	GetOccurrences(recurringId int64) (map[string]string, error)
+	AddOccurrence(recurringId int64, date string, status string, transactionId *int64) (bool, error)
+}
+
+type recurringTransactionRepository struct {
+	db dbtx
+}
+
+func GetRecurringTransactionRepository(db *sql.DB) *recurringTransactionRepository {
+	return &recurringTransactionRepository{db: db}
+}
+
+func (repo *recurringTransactionRepository) WithTx(tx *sql.Tx) RecurringTransactionRepository {
+	return &recurringTransactionRepository{db: tx}
+}
+
+const recurringTransactionColumns = `"Id", "UserId", "CategoryId", "AccountId", "Kind", "Price", "Currency", "Description", "Merchant", "Frequency", "Interval", "StartDate", "EndDate", "CreatedAt"`
+
+func scanRecurringTransaction(scanner rowScanner) (model.RecurringTransaction, error) {
+	var recurring model.RecurringTransaction
+	err := scanner.Scan(&recurring.Id, &recurring.UserId, &recurring.CategoryId, &recurring.AccountId, &recurring.Kind, &recurring.Price, &recurring.Currency,
+		&recurring.Description, &recurring.Merchant, &recurring.Frequency, &recurring.Interval, &recurring.StartDate, &recurring.EndDate, &recurring.CreatedAt)
+	return recurring, err
+}
+
+func (repo *recurringTransactionRepository) CreateRecurringTransaction(recurring model.RecurringTransaction) (int64, error) {
+	query := `
+        INSERT INTO "RecurringTransactions" ("UserId", "CategoryId", "AccountId", "Kind", "Price", "Currency", "Description", "Merchant", "Frequency", "Interval", "StartDate", "EndDate")
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	result, err := repo.db.Exec(query, recurring.UserId, recurring.CategoryId, recurring.AccountId, recurring.Kind, recurring.Price, recurring.Currency,
+		recurring.Description, recurring.Merchant, recurring.Frequency, recurring.Interval, recurring.StartDate, recurring.EndDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (repo *recurringTransactionRepository) GetRecurringTransactionById(id int64) (model.RecurringTransaction, error) {
+	query := `SELECT ` + recurringTransactionColumns + ` FROM "RecurringTransactions" WHERE "Id" = $1 LIMIT 1`
+	return scanRecurringTransaction(repo.db.QueryRow(query, id))
+}
+
+func (repo *recurringTransactionRepository) GetRecurringTransactions(userId int64) ([]model.RecurringTransaction, error) {
+	query := `SELECT ` + recurringTransactionColumns + ` FROM "RecurringTransactions" WHERE "UserId" = $1 ORDER BY "Id"`
+	return repo.queryRecurringTransactions(query, userId)
+}
+
+// GetStartedRecurringTransactions lists templates of all users that start on
+// or before date and so may have occurrences due. Templates that ended before
+// date are left out once their occurrences are handled up to the end, and so
+// are templates of users who can no longer edit the ledger of the template
+// category.
+func (repo *recurringTransactionRepository) GetStartedRecurringTransactions(date string) ([]model.RecurringTransaction, error) {
+	query := `
+        SELECT ` + recurringTransactionColumns + ` FROM "RecurringTransactions"
+        WHERE "StartDate" <= $1
+            AND ("EndDate" IS NULL OR "EndDate" >= $1 OR "EndDate" > COALESCE((
+                SELECT MAX("Date") FROM "RecurringOccurrences"
+                WHERE "RecurringOccurrences"."RecurringTransactionId" = "RecurringTransactions"."Id"), ''))
+            AND EXISTS (
+                SELECT 1 FROM "TransactionCategories"
+                INNER JOIN "LedgerMembers" ON "LedgerMembers"."LedgerId" = "TransactionCategories"."LedgerId"
+                WHERE "TransactionCategories"."Id" = "RecurringTransactions"."CategoryId"
+                    AND "LedgerMembers"."UserId" = "RecurringTransactions"."UserId"
+                    AND "LedgerMembers"."Role" IN ('owner', 'editor'))
+        ORDER BY "Id"`
+	return repo.queryRecurringTransactions(query, date)
+}
+
+func (repo *recurringTransactionRepository) queryRecurringTransactions(query string, args ...interface{}) ([]model.RecurringTransaction, error) {
+	recurringTransactions := []model.RecurringTransaction{}
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		recurring, err := scanRecurringTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		recurringTransactions = append(recurringTransactions, recurring)
+	}
+
+	return recurringTransactions, rows.Err()
+}
+
+func (repo *recurringTransactionRepository) UpdateRecurringTransaction(recurring model.RecurringTransaction) error {
+	query := `
+        UPDATE "RecurringTransactions" SET "CategoryId" = $1, "Price" = $2, "Description" = $3, "Merchant" = $4, "EndDate" = $5
+        WHERE "Id" = $6`
+	_, err := repo.db.Exec(query, recurring.CategoryId, recurring.Price, recurring.Description, recurring.Merchant, recurring.EndDate, recurring.Id)
+	return err
+}
+
+// DeleteRecurringTransaction removes the template and its occurrence log, the
+// transactions it already posted are kept.
+func (repo *recurringTransactionRepository) DeleteRecurringTransaction(id int64) error {
+	if _, err := repo.db.Exec(`DELETE FROM "RecurringOccurrences" WHERE "RecurringTransactionId" = $1`, id); err != nil {
+		return err
+	}
+	_, err := repo.db.Exec(`DELETE FROM "RecurringTransactions" WHERE "Id" = $1`, id)
+	return err
+}
+
+// GetOccurrences returns the status of every handled occurrence by its date.
+func (repo *recurringTransactionRepository) GetOccurrences(recurringId int64) (map[string]string, error) {
+	occurrences := map[string]string{}
+	query := `SELECT "Date", "Status" FROM "RecurringOccurrences" WHERE "RecurringTransactionId" = $1`
+	rows, err := repo.db.Query(query, recurringId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var date, status string
+		if err := rows.Scan(&date, &status); err != nil {
+			return nil, err
+		}
+		occurrences[date] = status
+	}
+
+	return occurrences, rows.Err()
+}
+
+// AddOccurrence records an occurrence as handled. It reports false when the
+// occurrence was already recorded.
+func (repo *recurringTransactionRepository) AddOccurrence(recurringId int64, date string, status string, transactionId *int64) (bool, error) {
+	query := `
+        INSERT INTO "RecurringOccurrences" ("RecurringTransactionId", "Date", "Status", "TransactionId") VALUES ($1, $2, $3, $4)
+        ON CONFLICT ("RecurringTransactionId", "Date") DO NOTHING`
+	result, err := repo.db.Exec(query, recurringId, date, status, transactionId)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"time"
+)
+
+type SessionRepository interface {
+	WithTx(tx *sql.Tx) SessionRepository
+	CreateSession(session model.Session, expiresAt time.Time) error
+	GetSessionById(id string) (model.Session, error)
+	GetActiveSessions(userId int64) ([]model.Session, error)
+	IsSessionActive(id string) (bool, error)
+	ExtendSession(id string, expiresAt time.Time) error
+	RevokeSession(id string) error
+	RevokeUserSessions(userId int64) error
+	CreateRefreshToken(tokenHash string, sessionId string) error
+	FindRefreshToken(tokenHash string) (model.RefreshToken, error)
+	UseRefreshToken(tokenHash string) (bool, error)
+}
+
+type sessionRepository struct {
+	db dbtx
+}
+
+func GetSessionRepository(db *sql.DB) *sessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (repo *sessionRepository) WithTx(tx *sql.Tx) SessionRepository {
+	return &sessionRepository{db: tx}
+}
+
+const sessionColumns = `"Id", "UserId", "UserAgent", "CreatedAt", "LastUsedAt", "ExpiresAt", "RevokedAt"`
+
+func scanSession(scanner rowScanner) (model.Session, error) {
+	var session model.Session
+	err := scanner.Scan(&session.Id, &session.UserId, &session.UserAgent, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt)
+	return session, err
+}
+
+func (repo *sessionRepository) CreateSession(session model.Session, expiresAt time.Time) error {
+	query := `INSERT INTO "Sessions" ("Id", "UserId", "UserAgent", "ExpiresAt") VALUES ($1, $2, $3, $4)`
+	_, err := repo.db.Exec(query, session.Id, session.UserId, session.UserAgent, formatSqlTime(expiresAt))
+	return err
+}
+
+func (repo *sessionRepository) GetSessionById(id string) (model.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM "Sessions" WHERE "Id" = $1 LIMIT 1`
+	return scanSession(repo.db.QueryRow(query, id))
+}
+
+func (repo *sessionRepository) GetActiveSessions(userId int64) ([]model.Session, error) {
+	sessions := []model.Session{}
+	query := `
+        SELECT ` + sessionColumns + ` FROM "Sessions"
+        WHERE "UserId" = $1 AND "RevokedAt" IS NULL AND "ExpiresAt" > $2
+        ORDER BY "LastUsedAt" DESC`
+	rows, err := repo.db.Query(query, userId, formatSqlTime(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (repo *sessionRepository) IsSessionActive(id string) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS (SELECT 1 FROM "Sessions" WHERE "Id" = $1 AND "RevokedAt" IS NULL AND "ExpiresAt" > $2)`
+	err := repo.db.QueryRow(query, id, formatSqlTime(time.Now())).Scan(&active)
+	return active, err
+}
+
+func (repo *sessionRepository) ExtendSession(id string, expiresAt time.Time) error {
+	query := `UPDATE "Sessions" SET "ExpiresAt" = $1, "LastUsedAt" = CURRENT_TIMESTAMP WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, formatSqlTime(expiresAt), id)
+	return err
+}
+
+func (repo *sessionRepository) RevokeSession(id string) error {
+	query := `UPDATE "Sessions" SET "RevokedAt" = CURRENT_TIMESTAMP WHERE "Id" = $1 AND "RevokedAt" IS NULL`
+	_, err := repo.db.Exec(query, id)
+	return err
+}
+
+func (repo *sessionRepository) RevokeUserSessions(userId int64) error {
+	query := `UPDATE "Sessions" SET "RevokedAt" = CURRENT_TIMESTAMP WHERE "UserId" = $1 AND "RevokedAt" IS NULL`
+	_, err := repo.db.Exec(query, userId)
+	return err
+}
+
+func (repo *sessionRepository) CreateRefreshToken(tokenHash string, sessionId string) error {
+	query := `INSERT INTO "RefreshTokens" ("TokenHash", "SessionId") VALUES ($1, $2)`
+	_, err := repo.db.Exec(query, tokenHash, sessionId)
+	return err
+}
+
+func (repo *sessionRepository) FindRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	var token model.RefreshToken
+	query := `SELECT "TokenHash", "SessionId", "UsedAt" FROM "RefreshTokens" WHERE "TokenHash" = $1 LIMIT 1`
+	err := repo.db.QueryRow(query, tokenHash).Scan(&token.TokenHash, &token.SessionId, &token.UsedAt)
+	return token, err
+}
+
+// UseRefreshToken marks the token as used. It reports false when the token
+// was already used, which means it is being replayed.
+func (repo *sessionRepository) UseRefreshToken(tokenHash string) (bool, error) {
+	query := `UPDATE "RefreshTokens" SET "UsedAt" = CURRENT_TIMESTAMP WHERE "TokenHash" = $1 AND "UsedAt" IS NULL`
+	result, err := repo.db.Exec(query, tokenHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
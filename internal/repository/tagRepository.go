@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"strconv"
+	"strings"
+)
+
+type TagRepository interface {
+	WithTx(tx *sql.Tx) TagRepository
+	CreateTag(tag model.Tag) (int64, error)
+	GetTagById(id int64) (model.Tag, error)
+	GetTags(ledgerId int64) ([]model.Tag, error)
+	UpdateTag(id int64, name string) error
+	DeleteTag(id int64) error
+	SetTransactionTags(transactionId int64, tagIds []int64) error
+	GetTransactionTags(transactionIds []int64) (map[int64][]model.Tag, error)
+}
+
+type tagRepository struct {
+	db dbtx
+}
+
+func GetTagRepository(db *sql.DB) *tagRepository {
+	return &tagRepository{db: db}
+}
+
+func (repo *tagRepository) WithTx(tx *sql.Tx) TagRepository {
+	return &tagRepository{db: tx}
+}
+
+func (repo *tagRepository) CreateTag(tag model.Tag) (int64, error) {
+	query := `INSERT INTO "Tags" ("LedgerId", "Name") VALUES ($1, $2)`
+	result, err := repo.db.Exec(query, tag.LedgerId, tag.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (repo *tagRepository) GetTagById(id int64) (model.Tag, error) {
+	var tag model.Tag
+	query := `SELECT "Id", "LedgerId", "Name", "CreatedAt" FROM "Tags" WHERE "Id" = $1 LIMIT 1`
+	err := repo.db.QueryRow(query, id).Scan(&tag.Id, &tag.LedgerId, &tag.Name, &tag.CreatedAt)
+	return tag, err
+}
+
+func (repo *tagRepository) GetTags(ledgerId int64) ([]model.Tag, error) {
+	tags := []model.Tag{}
+	query := `SELECT "Id", "LedgerId", "Name", "CreatedAt" FROM "Tags" WHERE "LedgerId" = $1 ORDER BY "Name"`
+	rows, err := repo.db.Query(query, ledgerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag model.Tag
+		if err := rows.Scan(&tag.Id, &tag.LedgerId, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (repo *tagRepository) UpdateTag(id int64, name string) error {
+	query := `UPDATE "Tags" SET "Name" = $1 WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, name, id)
+	return err
+}
+
+// DeleteTag removes the tag from its transactions and deletes it. It has to
+// run inside a transaction, see WithTx.
+func (repo *tagRepository) DeleteTag(id int64) error {
+	if _, err := repo.db.Exec(`DELETE FROM "TransactionTags" WHERE "TagId" = $1`, id); err != nil {
+		return err
+	}
+	_, err := repo.db.Exec(`DELETE FROM "Tags" WHERE "Id" = $1`, id)
+	return err
+}
+
+// SetTransactionTags replaces the tags of a transaction. It has to run inside
+// a transaction, see WithTx.
+func (repo *tagRepository) SetTransactionTags(transactionId int64, tagIds []int64) error {
+	if _, err := repo.db.Exec(`DELETE FROM "TransactionTags" WHERE "TransactionId" = $1`, transactionId); err != nil {
+		return err
+	}
+
+	query := `INSERT OR IGNORE INTO "TransactionTags" ("TransactionId", "TagId") VALUES ($1, $2)`
+	for _, tagId := range tagIds {
+		if _, err := repo.db.Exec(query, transactionId, tagId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *tagRepository) GetTransactionTags(transactionIds []int64) (map[int64][]model.Tag, error) {
+	tags := map[int64][]model.Tag{}
+	if len(transactionIds) == 0 {
+		return tags, nil
+	}
+
+	placeholders := make([]string, len(transactionIds))
+	args := make([]interface{}, len(transactionIds))
+	for i, id := range transactionIds {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+
+	query := `
+        SELECT tt."TransactionId", t."Id", t."LedgerId", t."Name", t."CreatedAt"
+        FROM "TransactionTags" tt JOIN "Tags" t ON t."Id" = tt."TagId"
+        WHERE tt."TransactionId" IN (` + strings.Join(placeholders, ", ") + `)
+        ORDER BY tt."TransactionId", t."Name"`
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionId int64
+		var tag model.Tag
+		if err := rows.Scan(&transactionId, &tag.Id, &tag.LedgerId, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags[transactionId] = append(tags[transactionId], tag)
+	}
+
+	return tags, rows.Err()
+}
@@ -3,41 +3,67 @@ package repository
 import (
 	"database/sql"
 	"expenses_tracker/internal/model"
+	"time"
 )
 
 type TransactionCategoryRepository interface {
-	CreateTransactionCategory(category model.TransactionCategory) error
+	WithTx(tx *sql.Tx) TransactionCategoryRepository
+	CreateTransactionCategory(category model.TransactionCategory) (int64, error)
 	GetTransactionCategoryById(categoryId int64) (model.TransactionCategory, error)
-	GetTransactionCategories(userId int64) ([]model.TransactionCategory, error)
+	GetTransactionCategories(ledgerId int64, includeArchived bool) ([]model.TransactionCategory, error)
 	DeleteTransactionCategory(id int64) error
-	UpdateCategoryById(id int64, name string, color string) error
+	IsCategoryUsed(id int64) (bool, error)
+	ReassignCategory(id int64, targetId int64, userId int64) error
+	DeleteCategoryTransactions(id int64, userId int64) error
+	SetArchived(id int64, archived bool) error
+	UpdateCategoryById(id int64, name string, color string, icon string) error
+	SetSortOrder(id int64, sortOrder int64) error
+	SetParent(id int64, parentId *int64) error
+	IsDescendant(id int64, ancestorId int64) (bool, error)
+	GetDeletedCategoryById(id int64) (model.TransactionCategory, error)
+	GetDeletedCategories(ledgerId int64) ([]model.TransactionCategory, error)
+	RestoreCategory(id int64, userId int64) error
+	PurgeDeletedCategories(before time.Time) (int64, error)
 }
 
 type transactionCategoryRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func GetTransactionCategoryRepository(db *sql.DB) *transactionCategoryRepository {
 	return &transactionCategoryRepository{db: db}
 }
 
-func (repo *transactionCategoryRepository) CreateTransactionCategory(category model.TransactionCategory) error {
-	query := `INSERT INTO "TransactionCategories" ("UserId", "Name", "Color") VALUES ($1, $2, $3)`
-	_, err := repo.db.Exec(query, category.UserId, category.Name, category.Color)
-	return err
+func (repo *transactionCategoryRepository) WithTx(tx *sql.Tx) TransactionCategoryRepository {
+	return &transactionCategoryRepository{db: tx}
+}
+
+func (repo *transactionCategoryRepository) CreateTransactionCategory(category model.TransactionCategory) (int64, error) {
+	// New categories go to the end of the list.
+	query := `
+        INSERT INTO "TransactionCategories" ("UserId", "LedgerId", "ParentId", "Name", "Color", "Icon", "SortOrder")
+        VALUES ($1, $2, $3, $4, $5, $6, (SELECT COALESCE(MAX("SortOrder"), -1) + 1 FROM "TransactionCategories" WHERE "LedgerId" = $2))`
+	result, err := repo.db.Exec(query, category.UserId, category.LedgerId, category.ParentId, category.Name, category.Color, category.Icon)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
 }
 
 func (repo *transactionCategoryRepository) GetTransactionCategoryById(categoryId int64) (model.TransactionCategory, error) {
 	var category model.TransactionCategory
-	query := `SELECT "Id", "UserId", "Name", "Color" FROM "TransactionCategories" WHERE "Id" = $1 LIMIT 1`
-	err := repo.db.QueryRow(query, categoryId).Scan(&category.Id, &category.UserId, &category.Name, &category.Color)
+	query := `SELECT "Id", "UserId", "LedgerId", "ParentId", "Name", "Color", "Icon", "SortOrder", "ArchivedAt" FROM "TransactionCategories" WHERE "Id" = $1 AND "DeletedAt" IS NULL LIMIT 1`
+	err := repo.db.QueryRow(query, categoryId).Scan(&category.Id, &category.UserId, &category.LedgerId, &category.ParentId, &category.Name, &category.Color, &category.Icon, &category.SortOrder, &category.ArchivedAt)
 	return category, err
 }
 
-func (repo *transactionCategoryRepository) GetTransactionCategories(userId int64) ([]model.TransactionCategory, error) {
+func (repo *transactionCategoryRepository) GetTransactionCategories(ledgerId int64, includeArchived bool) ([]model.TransactionCategory, error) {
 	var categories []model.TransactionCategory = []model.TransactionCategory{}
-	query := `SELECT "Id", "UserId", "Name", "Color" FROM "TransactionCategories" WHERE "UserId" = $1`
-	rows, err := repo.db.Query(query, userId)
+	query := `
+        SELECT "Id", "UserId", "LedgerId", "ParentId", "Name", "Color", "Icon", "SortOrder", "ArchivedAt" FROM "TransactionCategories"
+        WHERE "LedgerId" = $1 AND ($2 OR "ArchivedAt" IS NULL) AND "DeletedAt" IS NULL
+        ORDER BY "SortOrder", "Id"`
+	rows, err := repo.db.Query(query, ledgerId, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -45,7 +71,7 @@ func (repo *transactionCategoryRepository) GetTransactionCategories(userId int64
 
 	for rows.Next() {
 		var category model.TransactionCategory
-		if err := rows.Scan(&category.Id, &category.UserId, &category.Name, &category.Color); err != nil {
+		if err := rows.Scan(&category.Id, &category.UserId, &category.LedgerId, &category.ParentId, &category.Name, &category.Color, &category.Icon, &category.SortOrder, &category.ArchivedAt); err != nil {
 			return nil, err
 		}
 		categories = append(categories, category)
@@ -54,14 +80,209 @@ func (repo *transactionCategoryRepository) GetTransactionCategories(userId int64
 	return categories, nil
 }
 
+// DeleteTransactionCategory moves the children of the category up to its own
+// parent and moves the category to the trash, where its budgets stay with it.
+// Its transactions have to be reassigned first or deleted right after, see
+// IsCategoryUsed and DeleteCategoryTransactions. It has to run inside a
+// transaction, see WithTx.
 func (repo *transactionCategoryRepository) DeleteTransactionCategory(id int64) error {
-	query := `DELETE FROM "TransactionCategories" WHERE "Id" = $1`
-	_, err := repo.db.Exec(query, id)
+	query := `
+        UPDATE "TransactionCategories"
+        SET "ParentId" = (SELECT "ParentId" FROM "TransactionCategories" WHERE "Id" = $1)
+        WHERE "ParentId" = $1`
+	if _, err := repo.db.Exec(query, id); err != nil {
+		return err
+	}
+
+	_, err := repo.db.Exec(`UPDATE "TransactionCategories" SET "DeletedAt" = CURRENT_TIMESTAMP WHERE "Id" = $1 AND "DeletedAt" IS NULL`, id)
+	return err
+}
+
+func scanCategory(scanner rowScanner) (model.TransactionCategory, error) {
+	var category model.TransactionCategory
+	err := scanner.Scan(&category.Id, &category.UserId, &category.LedgerId, &category.ParentId, &category.Name, &category.Color, &category.Icon, &category.SortOrder, &category.ArchivedAt, &category.DeletedAt)
+	return category, err
+}
+
+func (repo *transactionCategoryRepository) GetDeletedCategoryById(id int64) (model.TransactionCategory, error) {
+	query := `
+        SELECT "Id", "UserId", "LedgerId", "ParentId", "Name", "Color", "Icon", "SortOrder", "ArchivedAt", "DeletedAt" FROM "TransactionCategories"
+        WHERE "Id" = $1 AND "DeletedAt" IS NOT NULL LIMIT 1`
+	return scanCategory(repo.db.QueryRow(query, id))
+}
+
+// GetDeletedCategories lists the categories in the trash of the ledger, most
+// recently deleted first.
+func (repo *transactionCategoryRepository) GetDeletedCategories(ledgerId int64) ([]model.TransactionCategory, error) {
+	categories := []model.TransactionCategory{}
+	query := `
+        SELECT "Id", "UserId", "LedgerId", "ParentId", "Name", "Color", "Icon", "SortOrder", "ArchivedAt", "DeletedAt" FROM "TransactionCategories"
+        WHERE "LedgerId" = $1 AND "DeletedAt" IS NOT NULL
+        ORDER BY "DeletedAt" DESC, "Id" DESC`
+	rows, err := repo.db.Query(query, ledgerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+// RestoreCategory takes the category out of the trash together with the
+// transactions deleted along with it. It goes to the top level when its
+// parent is in the trash too, its former children stay where they were moved.
+// It has to run inside a transaction, see WithTx.
+func (repo *transactionCategoryRepository) RestoreCategory(id int64, userId int64) error {
+	query := `
+        INSERT INTO "TransactionHistory" ("TransactionId", "LedgerId", "UserId", "Action", "Changes")
+        SELECT "Id", "LedgerId", $1, $2, '{}' FROM "Transactions"
+        WHERE "CategoryId" = $3 AND "DeletedAt" IS NOT NULL AND "DeletedWithCategoryId" = $3`
+	if _, err := repo.db.Exec(query, userId, model.TransactionActionRestore, id); err != nil {
+		return err
+	}
+
+	query = `
+        UPDATE "Transactions" SET "DeletedAt" = NULL, "DeletedWithCategoryId" = NULL
+        WHERE "CategoryId" = $1 AND "DeletedAt" IS NOT NULL AND "DeletedWithCategoryId" = $1`
+	if _, err := repo.db.Exec(query, id); err != nil {
+		return err
+	}
+
+	query = `
+        UPDATE "TransactionCategories" SET "ParentId" = NULL
+        WHERE "Id" = $1 AND "ParentId" IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL)`
+	if _, err := repo.db.Exec(query, id); err != nil {
+		return err
+	}
+
+	_, err := repo.db.Exec(`UPDATE "TransactionCategories" SET "DeletedAt" = NULL WHERE "Id" = $1`, id)
 	return err
 }
 
-func (repo *transactionCategoryRepository) UpdateCategoryById(id int64, name string, color string) error {
-	query := `UPDATE "TransactionCategories" SET "Name" = $1, "Color" = $2 WHERE "Id" = $3`
-	_, err := repo.db.Exec(query, name, color, id)
+// PurgeDeletedCategories removes categories deleted before the given time for
+// good, together with their budgets and the transactions still in the trash
+// with them. It has to run inside a transaction, see WithTx.
+func (repo *transactionCategoryRepository) PurgeDeletedCategories(before time.Time) (int64, error) {
+	deleted := `SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" < $1`
+	queries := []string{
+		`DELETE FROM "TransactionHistory" WHERE "TransactionId" IN (SELECT "Id" FROM "Transactions" WHERE "CategoryId" IN (` + deleted + `))`,
+		`DELETE FROM "TransactionSplits" WHERE "TransactionId" IN (SELECT "Id" FROM "Transactions" WHERE "CategoryId" IN (` + deleted + `))`,
+		`DELETE FROM "TransactionTags" WHERE "TransactionId" IN (SELECT "Id" FROM "Transactions" WHERE "CategoryId" IN (` + deleted + `))`,
+		`DELETE FROM "Transactions" WHERE "CategoryId" IN (` + deleted + `)`,
+		`DELETE FROM "Budgets" WHERE "CategoryId" IN (` + deleted + `)`,
+		`UPDATE "TransactionCategories" SET "ParentId" = NULL WHERE "ParentId" IN (` + deleted + `)`,
+	}
+	for _, query := range queries {
+		if _, err := repo.db.Exec(query, formatSqlTime(before)); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := repo.db.Exec(`DELETE FROM "TransactionCategories" WHERE "DeletedAt" < $1`, formatSqlTime(before))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (repo *transactionCategoryRepository) UpdateCategoryById(id int64, name string, color string, icon string) error {
+	query := `UPDATE "TransactionCategories" SET "Name" = $1, "Color" = $2, "Icon" = $3 WHERE "Id" = $4`
+	_, err := repo.db.Exec(query, name, color, icon, id)
+	return err
+}
+
+func (repo *transactionCategoryRepository) SetSortOrder(id int64, sortOrder int64) error {
+	query := `UPDATE "TransactionCategories" SET "SortOrder" = $1 WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, sortOrder, id)
+	return err
+}
+
+func (repo *transactionCategoryRepository) SetParent(id int64, parentId *int64) error {
+	query := `UPDATE "TransactionCategories" SET "ParentId" = $1 WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, parentId, id)
+	return err
+}
+
+// IsDescendant tells whether id is ancestorId itself or lies anywhere below it.
+func (repo *transactionCategoryRepository) IsDescendant(id int64, ancestorId int64) (bool, error) {
+	query := `
+        WITH RECURSIVE "Subtree"("Id") AS (
+            SELECT $1
+            UNION SELECT c."Id" FROM "TransactionCategories" c JOIN "Subtree" ON c."ParentId" = "Subtree"."Id"
+        )
+        SELECT EXISTS (SELECT 1 FROM "Subtree" WHERE "Id" = $2)`
+	var found bool
+	err := repo.db.QueryRow(query, ancestorId, id).Scan(&found)
+	return found, err
+}
+
+// IsCategoryUsed tells whether any transaction or recurring transaction is in
+// the category. Transactions in the trash don't count.
+func (repo *transactionCategoryRepository) IsCategoryUsed(id int64) (bool, error) {
+	var used bool
+	query := `
+        SELECT EXISTS (SELECT 1 FROM "Transactions" WHERE "CategoryId" = $1 AND "DeletedAt" IS NULL)
+            OR EXISTS (SELECT 1 FROM "RecurringTransactions" WHERE "CategoryId" = $1)`
+	err := repo.db.QueryRow(query, id).Scan(&used)
+	return used, err
+}
+
+// ReassignCategory moves transactions and recurring transactions of the
+// category to targetId, including transactions in the trash, so they can be
+// restored without the category. It has to run inside a transaction, see
+// WithTx.
+func (repo *transactionCategoryRepository) ReassignCategory(id int64, targetId int64, userId int64) error {
+	query := `
+        INSERT INTO "TransactionHistory" ("TransactionId", "LedgerId", "UserId", "Action", "Changes")
+        SELECT "Id", "LedgerId", $1, $2, json_object('categoryId', json_object('old', "CategoryId", 'new', $3))
+        FROM "Transactions" WHERE "CategoryId" = $4`
+	if _, err := repo.db.Exec(query, userId, model.TransactionActionUpdate, targetId, id); err != nil {
+		return err
+	}
+
+	if _, err := repo.db.Exec(`UPDATE "Transactions" SET "CategoryId" = $1 WHERE "CategoryId" = $2`, targetId, id); err != nil {
+		return err
+	}
+	_, err := repo.db.Exec(`UPDATE "RecurringTransactions" SET "CategoryId" = $1 WHERE "CategoryId" = $2`, targetId, id)
+	return err
+}
+
+// DeleteCategoryTransactions moves transactions of the category to the trash
+// along with the category, so restoring it brings them back, and
+// deletes its recurring transactions. It has to run inside a transaction
+// right after DeleteTransactionCategory, see WithTx.
+func (repo *transactionCategoryRepository) DeleteCategoryTransactions(id int64, userId int64) error {
+	query := `
+        INSERT INTO "TransactionHistory" ("TransactionId", "LedgerId", "UserId", "Action", "Changes")
+        SELECT "Id", "LedgerId", $1, $2, '{}' FROM "Transactions" WHERE "CategoryId" = $3 AND "DeletedAt" IS NULL`
+	if _, err := repo.db.Exec(query, userId, model.TransactionActionDelete, id); err != nil {
+		return err
+	}
+
+	queries := []string{
+		`UPDATE "Transactions" SET "DeletedAt" = (SELECT "DeletedAt" FROM "TransactionCategories" WHERE "Id" = $1), "DeletedWithCategoryId" = $1
+        WHERE "CategoryId" = $1 AND "DeletedAt" IS NULL`,
+		`DELETE FROM "RecurringOccurrences" WHERE "RecurringTransactionId" IN (SELECT "Id" FROM "RecurringTransactions" WHERE "CategoryId" = $1)`,
+		`DELETE FROM "RecurringTransactions" WHERE "CategoryId" = $1`,
+	}
+	for _, query := range queries {
+		if _, err := repo.db.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *transactionCategoryRepository) SetArchived(id int64, archived bool) error {
+	query := `UPDATE "TransactionCategories" SET "ArchivedAt" = CASE WHEN $1 THEN COALESCE("ArchivedAt", CURRENT_TIMESTAMP) END WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, archived, id)
 	return err
 }
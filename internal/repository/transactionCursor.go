@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/utils"
+	"strconv"
+)
+
+const (
+	TransactionSortDate      = "date"
+	TransactionSortPrice     = "price"
+	TransactionSortCreatedAt = "createdAt"
+)
+
+var transactionSortSql = map[string]string{
+	TransactionSortDate:      `"Transactions"."Date"`,
+	TransactionSortPrice:     `"Transactions"."Price"`,
+	TransactionSortCreatedAt: `"Transactions"."CreatedAt"`,
+}
+
+// TransactionOrder sorts transactions by Field, then by id in the same
+// direction. With After set, only transactions following it are returned,
+// which pages through the list without OFFSET.
+type TransactionOrder struct {
+	Field      string
+	Descending bool
+	After      *TransactionCursor
+}
+
+// TransactionCursor points at the last transaction of a page. Clients get it
+// as an opaque string, see EncodeTransactionCursor.
+type TransactionCursor struct {
+	Field      string `json:"f"`
+	Descending bool   `json:"d"`
+	Value      string `json:"v"`
+	Id         int64  `json:"i"`
+}
+
+func IsValidTransactionSort(field string) bool {
+	_, ok := transactionSortSql[field]
+	return ok
+}
+
+func EncodeTransactionCursor(cursor TransactionCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTransactionCursor parses a cursor and makes sure it was made for the
+// same order, because its position means nothing in any other one.
+func DecodeTransactionCursor(value string, field string, descending bool) (TransactionCursor, error) {
+	var cursor TransactionCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &cursor) != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	if cursor.Field != field || cursor.Descending != descending {
+		return cursor, errors.New("cursor was made for another sort order")
+	}
+	if cursor.Field == TransactionSortPrice {
+		if _, err := strconv.ParseInt(cursor.Value, 10, 64); err != nil {
+			return cursor, errors.New("invalid cursor")
+		}
+	}
+
+	return cursor, nil
+}
+
+// transactionCursorAt returns the cursor right after transaction. Dates are
+// kept in the form they are stored in, so they compare as plain strings.
+func transactionCursorAt(transaction model.Transaction, order TransactionOrder) (TransactionCursor, error) {
+	cursor := TransactionCursor{Field: order.Field, Descending: order.Descending, Id: transaction.Id}
+
+	switch order.Field {
+	case TransactionSortPrice:
+		cursor.Value = strconv.FormatInt(transaction.Price, 10)
+	case TransactionSortCreatedAt:
+		createdAt, err := utils.ParseDate(transaction.CreatedAt)
+		if err != nil {
+			return cursor, err
+		}
+		cursor.Value = formatSqlTime(createdAt)
+	default:
+		date, err := utils.ParseDate(transaction.Date)
+		if err != nil {
+			return cursor, err
+		}
+		cursor.Value = formatSqlTime(date)
+	}
+
+	return cursor, nil
+}
+
+// transactionCursorSql matches transactions that come after the cursor.
+func transactionCursorSql(cursor TransactionCursor, counter *utils.IncreasingCounter) (string, []interface{}) {
+	column := transactionSortSql[cursor.Field]
+	operator := ">"
+	if cursor.Descending {
+		operator = "<"
+	}
+
+	var value interface{} = cursor.Value
+	if cursor.Field == TransactionSortPrice {
+		value, _ = strconv.ParseInt(cursor.Value, 10, 64)
+	}
+
+	valuePlaceholder := "$" + strconv.Itoa(counter.Next())
+	idPlaceholder := "$" + strconv.Itoa(counter.Next())
+	condition := "(" + column + " " + operator + " " + valuePlaceholder +
+		" OR (" + column + " = " + valuePlaceholder + ` AND "Transactions"."Id" ` + operator + " " + idPlaceholder + "))"
+
+	return condition, []interface{}{value, cursor.Id}
+}
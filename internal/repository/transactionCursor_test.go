@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"encoding/base64"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/utils"
+	"reflect"
+	"testing"
+)
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	cursors := []TransactionCursor{
+		{Field: TransactionSortDate, Descending: true, Value: "2024-01-02 00:00:00", Id: 7},
+		{Field: TransactionSortPrice, Value: "4599", Id: 1},
+		{Field: TransactionSortCreatedAt, Descending: true, Value: "2024-01-02 10:11:12", Id: 1 << 40},
+	}
+
+	for _, cursor := range cursors {
+		t.Run(cursor.Field, func(t *testing.T) {
+			got, err := DecodeTransactionCursor(EncodeTransactionCursor(cursor), cursor.Field, cursor.Descending)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != cursor {
+				t.Errorf("decoded %+v, want %+v", got, cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeTransactionCursor(t *testing.T) {
+	encoded := EncodeTransactionCursor(TransactionCursor{Field: TransactionSortDate, Descending: true, Value: "2024-01-02 00:00:00", Id: 7})
+	badPrice := EncodeTransactionCursor(TransactionCursor{Field: TransactionSortPrice, Value: "cheap", Id: 7})
+
+	tests := []struct {
+		name       string
+		value      string
+		field      string
+		descending bool
+	}{
+		{"other field", encoded, TransactionSortPrice, true},
+		{"other direction", encoded, TransactionSortDate, false},
+		{"not base64", "not a cursor!", TransactionSortDate, true},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("date")), TransactionSortDate, true},
+		{"invalid price", badPrice, TransactionSortPrice, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeTransactionCursor(tt.value, tt.field, tt.descending); err == nil {
+				t.Error("DecodeTransactionCursor succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestTransactionCursorAt(t *testing.T) {
+	transaction := model.Transaction{Id: 3, Price: 250, Date: "2024-01-02T00:00:00Z", CreatedAt: "2024-01-05T10:11:12Z"}
+
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{TransactionSortDate, "2024-01-02 00:00:00"},
+		{TransactionSortPrice, "250"},
+		{TransactionSortCreatedAt, "2024-01-05 10:11:12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			cursor, err := transactionCursorAt(transaction, TransactionOrder{Field: tt.field, Descending: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := TransactionCursor{Field: tt.field, Descending: true, Value: tt.want, Id: 3}
+			if cursor != want {
+				t.Errorf("cursor = %+v, want %+v", cursor, want)
+			}
+		})
+	}
+}
+
+func TestTransactionCursorSql(t *testing.T) {
+	counter := utils.IncreasingCounter{}
+	counter.Next()
+
+	condition, args := transactionCursorSql(TransactionCursor{Field: TransactionSortPrice, Value: "250", Id: 3}, &counter)
+
+	wantCondition := `("Transactions"."Price" > $2 OR ("Transactions"."Price" = $2 AND "Transactions"."Id" > $3))`
+	if condition != wantCondition {
+		t.Errorf("condition = %s, want %s", condition, wantCondition)
+	}
+	if want := []interface{}{int64(250), int64(3)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
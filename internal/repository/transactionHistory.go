@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"encoding/json"
+	"expenses_tracker/internal/model"
+)
+
+// historyFields lists the fields of a transaction kept in its history, by
+// their JSON names.
+var historyFields = []string{"kind", "price", "currency", "categoryId", "accountId", "date", "description", "merchant", "notes"}
+
+func transactionHistoryValues(transaction *model.Transaction) map[string]interface{} {
+	if transaction == nil {
+		return map[string]interface{}{}
+	}
+
+	var categoryId, accountId interface{}
+	if transaction.CategoryId != 0 {
+		categoryId = transaction.CategoryId
+	}
+	if transaction.AccountId != nil {
+		accountId = *transaction.AccountId
+	}
+
+	return map[string]interface{}{
+		"kind":        transaction.Kind,
+		"price":       transaction.Price,
+		"currency":    transaction.Currency,
+		"categoryId":  categoryId,
+		"accountId":   accountId,
+		"date":        transaction.Date,
+		"description": transaction.Description,
+		"merchant":    transaction.Merchant,
+		"notes":       transaction.Notes,
+	}
+}
+
+// diffTransactions returns the fields that differ between two versions of a
+// transaction, old being nil for a new one.
+func diffTransactions(old *model.Transaction, new *model.Transaction) map[string]model.FieldChange {
+	oldValues := transactionHistoryValues(old)
+	newValues := transactionHistoryValues(new)
+
+	changes := map[string]model.FieldChange{}
+	for _, field := range historyFields {
+		if oldValues[field] != newValues[field] {
+			changes[field] = model.FieldChange{Old: oldValues[field], New: newValues[field]}
+		}
+	}
+	return changes
+}
+
+func (repo *transactionRepository) addHistory(transactionId int64, ledgerId int64, userId int64, action string, changes map[string]model.FieldChange) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO "TransactionHistory" ("TransactionId", "LedgerId", "UserId", "Action", "Changes")
+        VALUES ($1, $2, $3, $4, $5)`
+	_, err = repo.db.Exec(query, transactionId, ledgerId, userId, action, string(data))
+	return err
+}
+
+// GetHistory returns the changes of the transaction in the order they were
+// made, in the trash as well. Purges delete the history along with the
+// transaction, and rows left over from a transaction that had the id before
+// are skipped by starting at the latest create.
+func (repo *transactionRepository) GetHistory(transactionId int64) ([]model.TransactionChange, error) {
+	history := []model.TransactionChange{}
+	query := `
+        SELECT h."Id", h."TransactionId", h."LedgerId", h."UserId", COALESCE(u."Login", ''), h."Action", h."Changes", h."CreatedAt"
+        FROM "TransactionHistory" h
+        LEFT JOIN "Users" u ON u."Id" = h."UserId"
+        WHERE h."TransactionId" = $1 AND h."Id" >= COALESCE((
+            SELECT MAX("Id") FROM "TransactionHistory" WHERE "TransactionId" = $1 AND "Action" = $2), 0)
+        ORDER BY h."Id"`
+	rows, err := repo.db.Query(query, transactionId, model.TransactionActionCreate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change model.TransactionChange
+		var changes string
+		if err := rows.Scan(&change.Id, &change.TransactionId, &change.LedgerId, &change.UserId, &change.Login, &change.Action, &changes, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &change.Changes); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	return history, rows.Err()
+}
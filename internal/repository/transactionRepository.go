@@ -5,66 +5,250 @@ import (
 	"expenses_tracker/internal/model"
 	"expenses_tracker/internal/pkg/utils"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
+type TransactionFilter struct {
+	CategoryIds []int64
+	// TagIds keeps transactions with any of the tags, ExcludeTagIds drops
+	// transactions with any of them.
+	TagIds        []int64
+	ExcludeTagIds []int64
+	From          *time.Time
+	To            *time.Time
+	// MinPrice and MaxPrice bound the price in the transaction's own currency,
+	// both inclusive.
+	MinPrice *int64
+	MaxPrice *int64
+	Kind     string
+	Search   string
+	// Merchant matches merchants containing it, ignoring case.
+	Merchant string
+	// ConvertTo fills ConvertedPrice of every returned transaction when set.
+	ConvertTo string
+}
+
+type TotalFilter struct {
+	Year       int
+	Month      int
+	Day        int
+	CategoryId int64
+	// TagIds and ExcludeTagIds work like in TransactionFilter.
+	TagIds        []int64
+	ExcludeTagIds []int64
+	// ConvertTo sums prices converted into this currency instead of raw prices.
+	ConvertTo string
+}
+
+const (
+	StatsGroupByDay   = "day"
+	StatsGroupByWeek  = "week"
+	StatsGroupByMonth = "month"
+	StatsGroupByYear  = "year"
+)
+
+type StatsFilter struct {
+	From time.Time
+	// To is exclusive.
+	To          time.Time
+	GroupBy     string
+	ByCategory  bool
+	CategoryIds []int64
+	// ConvertTo sums prices converted into this currency instead of raw prices.
+	ConvertTo string
+}
+
 type TransactionRepository interface {
-	CreateTransaction(transaction model.Transaction) error
+	WithTx(tx *sql.Tx) TransactionRepository
+	CreateTransaction(transaction model.Transaction) (int64, error)
+	CreateTransfer(from model.Transaction, to model.Transaction) (int64, error)
 	GetTransactionById(transactionId int64) (model.Transaction, error)
-	GetTransactions(userId int64, categoryIds []int64, pagination SqlPagination) (PaginationResponse[model.Transaction], error)
-	UpdateTransaction(transaction model.Transaction) error
-	DeleteTransaction(id int64) error
-	GetTotalPriceByDateAndCategory(userId int64, year int, month int, day int, categoryId int64) (float64, error)
+	GetTransactionsAccess(transactionIds []int64, userId int64) (map[int64]TransactionAccess, error)
+	GetTransactionIds(ledgerId int64, filter TransactionFilter) ([]int64, error)
+	GetTransactions(ledgerId int64, filter TransactionFilter, order TransactionOrder, pagination SqlPagination) (PaginationResponse[model.Transaction], error)
+	ExportTransactions(ledgerId int64, filter TransactionFilter, fn func(model.Transaction) error) error
+	UpdateTransaction(transaction model.Transaction, userId int64) error
+	DeleteTransaction(id int64, userId int64) error
+	RestoreTransaction(id int64, userId int64) error
+	GetHistory(transactionId int64) ([]model.TransactionChange, error)
+	GetDeletedTransactionById(id int64) (model.Transaction, error)
+	GetDeletedTransactions(ledgerId int64) ([]model.Transaction, error)
+	PurgeDeletedTransactions(before time.Time) (int64, error)
+	GetTotalPriceByDateAndCategory(ledgerId int64, filter TotalFilter) (model.TransactionTotal, error)
+	GetTransactionStats(ledgerId int64, filter StatsFilter) (model.TransactionStats, error)
+	SetSplits(transactionId int64, splits []model.TransactionSplit) error
+	GetSplits(transactionIds []int64) (map[int64][]model.TransactionSplit, error)
+	GetBalances(ledgerId int64) ([]model.Balance, error)
 }
 
 type transactionRepository struct {
-	db *sql.DB
+	db dbtx
+}
+
+// TransactionAccess is a transaction together with the role the user has in
+// its ledger, which is empty when the user is not a member.
+type TransactionAccess struct {
+	Transaction model.Transaction
+	Role        string
 }
 
 func GetTransactionRepository(db *sql.DB) *transactionRepository {
 	return &transactionRepository{db: db}
 }
 
-func (repo *transactionRepository) CreateTransaction(transaction model.Transaction) error {
-	query := `INSERT INTO "Transactions" ("Price", "CategoryId", "UserId") VALUES ($1, $2, $3)`
-	_, err := repo.db.Exec(query, transaction.Price, transaction.CategoryId, transaction.UserId)
-	return err
+func (repo *transactionRepository) WithTx(tx *sql.Tx) TransactionRepository {
+	return &transactionRepository{db: tx}
+}
+
+// CreateTransaction stores the transaction and records it in its history as
+// created by its user. It has to run inside a transaction, see WithTx.
+func (repo *transactionRepository) CreateTransaction(transaction model.Transaction) (int64, error) {
+	var date interface{}
+	if transaction.Date != "" {
+		parsedDate, err := utils.ParseDate(transaction.Date)
+		if err != nil {
+			return 0, err
+		}
+		date = formatSqlTime(parsedDate)
+	}
+
+	// Without an explicit currency the transaction is in the user's base
+	// currency. Without an explicit ledger it goes to the ledger of its
+	// category, or to the user's default one when it has no category.
+	query := `
+        INSERT INTO "Transactions" ("Price", "CategoryId", "UserId", "Date", "Description", "Merchant", "Notes", "Currency", "Kind", "AccountId", "TransferId", "LedgerId")
+        VALUES ($1, NULLIF($2, 0), $3, COALESCE($4, CURRENT_TIMESTAMP), $5, $6, $7,
+            COALESCE(NULLIF($8, ''), (SELECT "BaseCurrency" FROM "Users" WHERE "Id" = $3)),
+            COALESCE(NULLIF($9, ''), 'expense'), $10, $11,
+            COALESCE(NULLIF($12, 0), (SELECT "LedgerId" FROM "TransactionCategories" WHERE "Id" = $2), (SELECT "DefaultLedgerId" FROM "Users" WHERE "Id" = $3)))`
+	result, err := repo.db.Exec(query, transaction.Price, transaction.CategoryId, transaction.UserId, date, transaction.Description, transaction.Merchant, transaction.Notes, transaction.Currency, transaction.Kind, transaction.AccountId, transaction.TransferId, transaction.LedgerId)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	// The stored row has the defaults filled in.
+	created, err := repo.GetTransactionById(id)
+	if err != nil {
+		return 0, err
+	}
+	if err := repo.addHistory(id, created.LedgerId, transaction.UserId, model.TransactionActionCreate, diffTransactions(nil, &created)); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// CreateTransfer stores both sides of a transfer and links them with the id
+// of the outgoing one. It has to run inside a transaction, see WithTx.
+func (repo *transactionRepository) CreateTransfer(from model.Transaction, to model.Transaction) (int64, error) {
+	fromId, err := repo.CreateTransaction(from)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `UPDATE "Transactions" SET "TransferId" = $1 WHERE "Id" = $1`
+	if _, err := repo.db.Exec(query, fromId); err != nil {
+		return 0, err
+	}
+
+	to.TransferId = &fromId
+	if _, err := repo.CreateTransaction(to); err != nil {
+		return 0, err
+	}
+
+	return fromId, nil
 }
 
 func (repo *transactionRepository) GetTransactionById(transactionId int64) (model.Transaction, error) {
 	var transaction model.Transaction
-	query := `SELECT "Id", "Price", "CategoryId", "CreatedAt", "UserId" FROM "Transactions" WHERE "Id" = $1 LIMIT 1`
-	err := repo.db.QueryRow(query, transactionId).Scan(&transaction.Id, &transaction.Price, &transaction.CategoryId, &transaction.CreatedAt, &transaction.UserId)
+	query := `
+        SELECT "Id", "Kind", "Price", "Currency", COALESCE("CategoryId", 0), "AccountId", "TransferId", "Date", "Description", "Merchant", "Notes", "CreatedAt", "LedgerId", "UserId"
+        FROM "Transactions" WHERE "Id" = $1 AND "DeletedAt" IS NULL LIMIT 1`
+	err := repo.db.QueryRow(query, transactionId).Scan(&transaction.Id, &transaction.Kind, &transaction.Price, &transaction.Currency, &transaction.CategoryId, &transaction.AccountId, &transaction.TransferId, &transaction.Date, &transaction.Description, &transaction.Merchant, &transaction.Notes, &transaction.CreatedAt, &transaction.LedgerId, &transaction.UserId)
 	if err != nil {
 		return model.Transaction{}, err
 	}
 	return transaction, nil
 }
 
-func (repo *transactionRepository) GetTransactions(userId int64, categoryIds []int64, pagination SqlPagination) (PaginationResponse[model.Transaction], error) {
-	var transactions []model.Transaction = []model.Transaction{}
+// GetTransactionsAccess loads the transactions with the given ids and the
+// user's role in their ledgers in one query. Missing ids are left out.
+func (repo *transactionRepository) GetTransactionsAccess(transactionIds []int64, userId int64) (map[int64]TransactionAccess, error) {
+	transactions := map[int64]TransactionAccess{}
+	if len(transactionIds) == 0 {
+		return transactions, nil
+	}
 
-	counter := &utils.IncreasingCounter{}
+	placeholders := make([]string, len(transactionIds))
+	args := []interface{}{userId}
+	for i, id := range transactionIds {
+		placeholders[i] = "$" + strconv.Itoa(i+2)
+		args = append(args, id)
+	}
 
-	mainQuery := `
-        SELECT "Transactions"."Id", "Price", "CategoryId", "CreatedAt", "Transactions"."UserId", cat."Id", cat."name", cat."color"
-        FROM "Transactions"
-        INNER JOIN "TransactionCategories" as cat on "Transactions"."CategoryId" = cat."Id"
-        WHERE "Transactions"."UserId" = ` + "$" + strconv.Itoa(counter.Next())
-	queryParams := []interface{}{userId}
+	query := `
+        SELECT t."Id", t."Kind", t."Price", t."Currency", COALESCE(t."CategoryId", 0), t."AccountId", t."TransferId", t."Date", t."Description", t."Merchant", t."Notes", t."CreatedAt", t."LedgerId", t."UserId",
+            COALESCE(m."Role", '')
+        FROM "Transactions" t
+        LEFT JOIN "LedgerMembers" m ON m."LedgerId" = t."LedgerId" AND m."UserId" = $1
+        WHERE t."Id" IN (` + strings.Join(placeholders, ", ") + `) AND t."DeletedAt" IS NULL`
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if len(categoryIds) > 0 {
-		placeholders := make([]string, len(categoryIds))
-		for i, categoryId := range categoryIds {
-			placeholders[i] = "$" + strconv.Itoa(counter.Next())
-			queryParams = append(queryParams, categoryId)
+	for rows.Next() {
+		var item TransactionAccess
+		transaction := &item.Transaction
+		err := rows.Scan(&transaction.Id, &transaction.Kind, &transaction.Price, &transaction.Currency, &transaction.CategoryId, &transaction.AccountId, &transaction.TransferId, &transaction.Date, &transaction.Description, &transaction.Merchant, &transaction.Notes, &transaction.CreatedAt, &transaction.LedgerId, &transaction.UserId, &item.Role)
+		if err != nil {
+			return nil, err
 		}
+		transactions[transaction.Id] = item
+	}
+
+	return transactions, rows.Err()
+}
 
-		mainQuery += " AND \"CategoryId\" IN ("
-		mainQuery += strings.Join(placeholders, ", ")
-		mainQuery += ")"
+// GetTransactionIds returns the ids of all transactions matching the filter.
+func (repo *transactionRepository) GetTransactionIds(ledgerId int64, filter TransactionFilter) ([]int64, error) {
+	counter := &utils.IncreasingCounter{}
+	query, queryParams := buildTransactionsQuery(ledgerId, filter, counter)
+	query = `SELECT "Id" FROM (` + query + `) ORDER BY "Id"`
+
+	rows, err := repo.db.Query(query, queryParams...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetTransactions returns a page of transactions together with the cursor of
+// the next page, which is empty on the last one.
+func (repo *transactionRepository) GetTransactions(ledgerId int64, filter TransactionFilter, order TransactionOrder, pagination SqlPagination) (PaginationResponse[model.Transaction], error) {
+	var transactions []model.Transaction = []model.Transaction{}
+
+	counter := &utils.IncreasingCounter{}
+	mainQuery, queryParams := buildTransactionsQuery(ledgerId, filter, counter)
 
 	countSubquery := fmt.Sprintf(`
         SELECT COUNT(*) 
@@ -75,8 +259,24 @@ func (repo *transactionRepository) GetTransactions(userId int64, categoryIds []i
 		return PaginationResponse[model.Transaction]{Items: transactions, Count: 0}, err
 	}
 
-	mainQuery += " ORDER BY \"Transactions\".\"CreatedAt\" DESC LIMIT $" + strconv.Itoa(counter.Next()) + " OFFSET $" + strconv.Itoa(counter.Next())
-	queryParams = append(queryParams, pagination.Limit, pagination.Offset)
+	if order.After != nil {
+		condition, args := transactionCursorSql(*order.After, counter)
+		mainQuery += " AND " + condition
+		queryParams = append(queryParams, args...)
+	}
+
+	sortColumn, ok := transactionSortSql[order.Field]
+	if !ok {
+		sortColumn = transactionSortSql[TransactionSortDate]
+	}
+	direction := "ASC"
+	if order.Descending {
+		direction = "DESC"
+	}
+
+	// One extra row tells whether there is a next page.
+	mainQuery += " ORDER BY " + sortColumn + " " + direction + ", \"Transactions\".\"Id\" " + direction + " LIMIT $" + strconv.Itoa(counter.Next()) + " OFFSET $" + strconv.Itoa(counter.Next())
+	queryParams = append(queryParams, pagination.Limit+1, pagination.Offset)
 
 	rows, err := repo.db.Query(mainQuery, queryParams...)
 	if err != nil {
@@ -85,58 +285,649 @@ func (repo *transactionRepository) GetTransactions(userId int64, categoryIds []i
 	defer rows.Close()
 
 	for rows.Next() {
-		var item model.Transaction
-		if err := rows.Scan(&item.Id, &item.Price, &item.CategoryId, &item.CreatedAt, &item.UserId, &item.Category.Id, &item.Category.Name, &item.Category.Color); err != nil {
+		item, err := scanTransactionRow(rows)
+		if err != nil {
 			return PaginationResponse[model.Transaction]{Items: transactions, Count: 0}, err
 		}
 		transactions = append(transactions, item)
 	}
 
-	return PaginationResponse[model.Transaction]{Items: transactions, Count: totalCount}, nil
+	response := PaginationResponse[model.Transaction]{Items: transactions, Count: totalCount}
+	if int64(len(transactions)) > pagination.Limit {
+		response.Items = transactions[:pagination.Limit]
+
+		cursor, err := transactionCursorAt(response.Items[len(response.Items)-1], order)
+		if err != nil {
+			return PaginationResponse[model.Transaction]{Items: []model.Transaction{}, Count: 0}, err
+		}
+		response.NextCursor = EncodeTransactionCursor(cursor)
+	}
+
+	return response, nil
 }
 
-func (repo *transactionRepository) DeleteTransaction(id int64) error {
-	query := `DELETE FROM "Transactions" WHERE "Id" = $1`
-	_, err := repo.db.Exec(query, id)
-	return err
+func (repo *transactionRepository) ExportTransactions(ledgerId int64, filter TransactionFilter, fn func(model.Transaction) error) error {
+	counter := &utils.IncreasingCounter{}
+	query, queryParams := buildTransactionsQuery(ledgerId, filter, counter)
+	query += " ORDER BY \"Transactions\".\"Date\", \"Transactions\".\"Id\""
+
+	rows, err := repo.db.Query(query, queryParams...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanTransactionRow(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
-func (repo *transactionRepository) UpdateTransaction(transaction model.Transaction) error {
-	query := `UPDATE "Transactions" SET "Price" = $1 WHERE "Id" = $2`
-	_, err := repo.db.Exec(query, transaction.Price, transaction.Id)
-	return err
+func buildTransactionsQuery(ledgerId int64, filter TransactionFilter, counter *utils.IncreasingCounter) (string, []interface{}) {
+	convertedPrice := "NULL"
+	queryParams := []interface{}{}
+	if filter.ConvertTo != "" {
+		convertedPrice = convertedPriceSql("$" + strconv.Itoa(counter.Next()))
+		queryParams = append(queryParams, filter.ConvertTo)
+	}
+
+	query := `
+        SELECT "Transactions"."Id", "Kind", "Price", "Currency", ` + convertedPrice + `, COALESCE("CategoryId", 0), "AccountId", "TransferId",
+            "Date", "Description", "Merchant", "Notes", "CreatedAt", "Transactions"."LedgerId", "Transactions"."UserId",
+            COALESCE(cat."Id", 0), COALESCE(cat."name", ''), COALESCE(cat."color", '')
+        FROM "Transactions"
+        LEFT JOIN "TransactionCategories" as cat on "Transactions"."CategoryId" = cat."Id"
+        WHERE "Transactions"."DeletedAt" IS NULL AND "Transactions"."LedgerId" = ` + "$" + strconv.Itoa(counter.Next())
+	queryParams = append(queryParams, ledgerId)
+
+	if len(filter.CategoryIds) > 0 {
+		condition, args := categorySubtreeSql(filter.CategoryIds, counter)
+		query += " AND " + condition
+		queryParams = append(queryParams, args...)
+	}
+
+	if len(filter.TagIds) > 0 {
+		condition, args := tagFilterSql(`"Transactions"."Id"`, filter.TagIds, false, counter)
+		query += " AND " + condition
+		queryParams = append(queryParams, args...)
+	}
+
+	if len(filter.ExcludeTagIds) > 0 {
+		condition, args := tagFilterSql(`"Transactions"."Id"`, filter.ExcludeTagIds, true, counter)
+		query += " AND " + condition
+		queryParams = append(queryParams, args...)
+	}
+
+	if filter.From != nil {
+		query += " AND \"Transactions\".\"Date\" >= $" + strconv.Itoa(counter.Next())
+		queryParams = append(queryParams, formatSqlTime(*filter.From))
+	}
+
+	if filter.To != nil {
+		query += " AND \"Transactions\".\"Date\" < $" + strconv.Itoa(counter.Next())
+		queryParams = append(queryParams, formatSqlTime(*filter.To))
+	}
+
+	if filter.MinPrice != nil {
+		query += " AND \"Price\" >= $" + strconv.Itoa(counter.Next())
+		queryParams = append(queryParams, *filter.MinPrice)
+	}
+
+	if filter.MaxPrice != nil {
+		query += " AND \"Price\" <= $" + strconv.Itoa(counter.Next())
+		queryParams = append(queryParams, *filter.MaxPrice)
+	}
+
+	if filter.Kind != "" {
+		query += " AND \"Kind\" = $" + strconv.Itoa(counter.Next())
+		queryParams = append(queryParams, filter.Kind)
+	}
+
+	if filter.Merchant != "" {
+		query += " AND \"Merchant\" LIKE $" + strconv.Itoa(counter.Next()) + " ESCAPE '\\'"
+		queryParams = append(queryParams, "%"+escapeLike(filter.Merchant)+"%")
+	}
+
+	if matchQuery := buildMatchQuery(filter.Search); matchQuery != "" {
+		query += ` AND "Transactions"."Id" IN (SELECT docid FROM "TransactionsSearch" WHERE "TransactionsSearch" MATCH $` + strconv.Itoa(counter.Next()) + ")"
+		queryParams = append(queryParams, matchQuery)
+	}
+
+	return query, queryParams
+}
+
+// categorySubtreeSql matches transactions in any of the categories or in
+// their descendants, so filtering by a parent category rolls its children up.
+func categorySubtreeSql(categoryIds []int64, counter *utils.IncreasingCounter) (string, []interface{}) {
+	selects := make([]string, len(categoryIds))
+	args := make([]interface{}, len(categoryIds))
+	for i, categoryId := range categoryIds {
+		selects[i] = "SELECT $" + strconv.Itoa(counter.Next())
+		args[i] = categoryId
+	}
+
+	return `"CategoryId" IN (
+            WITH RECURSIVE "Subtree"("Id") AS (
+                ` + strings.Join(selects, " UNION ") + `
+                UNION SELECT c."Id" FROM "TransactionCategories" c JOIN "Subtree" ON c."ParentId" = "Subtree"."Id"
+            )
+            SELECT "Id" FROM "Subtree")`, args
+}
+
+// tagFilterSql matches transactions by idColumn that have any of the tags, or
+// none of them when exclude is set.
+func tagFilterSql(idColumn string, tagIds []int64, exclude bool, counter *utils.IncreasingCounter) (string, []interface{}) {
+	placeholders := make([]string, len(tagIds))
+	args := make([]interface{}, len(tagIds))
+	for i, tagId := range tagIds {
+		placeholders[i] = "$" + strconv.Itoa(counter.Next())
+		args[i] = tagId
+	}
+
+	operator := "IN"
+	if exclude {
+		operator = "NOT IN"
+	}
+
+	return idColumn + " " + operator + ` (SELECT "TransactionId" FROM "TransactionTags" WHERE "TagId" IN (` + strings.Join(placeholders, ", ") + "))", args
+}
+
+// escapeLike makes LIKE treat % and _ in value as plain characters.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
-func (repo *transactionRepository) GetTotalPriceByDateAndCategory(userId int64, year int, month int, day int, categoryId int64) (float64, error) {
-	var totalPrice float64
+// buildMatchQuery turns free text into a full-text query where every word is
+// matched as a prefix, so user input can never break the MATCH syntax.
+func buildMatchQuery(search string) string {
+	terms := []string{}
+	for _, word := range strings.Fields(search) {
+		word = strings.NewReplacer(`"`, "", "*", "").Replace(word)
+		if word != "" {
+			terms = append(terms, `"`+word+`*"`)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+func scanTransactionRow(rows *sql.Rows) (model.Transaction, error) {
+	var item model.Transaction
+	err := rows.Scan(&item.Id, &item.Kind, &item.Price, &item.Currency, &item.ConvertedPrice, &item.CategoryId, &item.AccountId, &item.TransferId, &item.Date, &item.Description, &item.Merchant, &item.Notes, &item.CreatedAt, &item.LedgerId, &item.UserId, &item.Category.Id, &item.Category.Name, &item.Category.Color)
+	return item, err
+}
+
+// DeleteTransaction moves the transaction to the trash, keeping its splits and
+// tags for RestoreTransaction. Deleting either side of a transfer deletes the
+// whole transfer. It has to run inside a transaction, see WithTx.
+func (repo *transactionRepository) DeleteTransaction(id int64, userId int64) error {
+	return repo.setDeleted(id, userId, true)
+}
+
+// RestoreTransaction takes the transaction, or the whole transfer, out of the
+// trash. It has to run inside a transaction, see WithTx.
+func (repo *transactionRepository) RestoreTransaction(id int64, userId int64) error {
+	return repo.setDeleted(id, userId, false)
+}
+
+func (repo *transactionRepository) setDeleted(id int64, userId int64, deleted bool) error {
+	condition, action, deletedAt := `"DeletedAt" IS NULL`, model.TransactionActionDelete, "CURRENT_TIMESTAMP"
+	if !deleted {
+		condition, action, deletedAt = `"DeletedAt" IS NOT NULL`, model.TransactionActionRestore, "NULL"
+	}
+	sides := `("Id" = $1 OR "TransferId" = (SELECT "TransferId" FROM "Transactions" WHERE "Id" = $1)) AND ` + condition
+
+	rows, err := repo.db.Query(`SELECT "Id", "LedgerId" FROM "Transactions" WHERE `+sides, id)
+	if err != nil {
+		return err
+	}
+	var transactions []model.Transaction
+	for rows.Next() {
+		var transaction model.Transaction
+		if err := rows.Scan(&transaction.Id, &transaction.LedgerId); err != nil {
+			rows.Close()
+			return err
+		}
+		transactions = append(transactions, transaction)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := repo.db.Exec(`UPDATE "Transactions" SET "DeletedAt" = `+deletedAt+`, "DeletedWithCategoryId" = NULL WHERE `+sides, id); err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		if err := repo.addHistory(transaction.Id, transaction.LedgerId, userId, action, map[string]model.FieldChange{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const deletedTransactionColumns = `t."Id", t."Kind", t."Price", t."Currency", COALESCE(t."CategoryId", 0), t."AccountId", t."TransferId", t."Date", t."Description", t."Merchant", t."Notes", t."CreatedAt", t."LedgerId", t."UserId", t."DeletedAt",
+    COALESCE(cat."Id", 0), COALESCE(cat."Name", ''), COALESCE(cat."Color", '')`
+
+func scanDeletedTransaction(scanner rowScanner) (model.Transaction, error) {
+	var item model.Transaction
+	err := scanner.Scan(&item.Id, &item.Kind, &item.Price, &item.Currency, &item.CategoryId, &item.AccountId, &item.TransferId, &item.Date, &item.Description, &item.Merchant, &item.Notes, &item.CreatedAt, &item.LedgerId, &item.UserId, &item.DeletedAt, &item.Category.Id, &item.Category.Name, &item.Category.Color)
+	return item, err
+}
+
+func (repo *transactionRepository) GetDeletedTransactionById(id int64) (model.Transaction, error) {
+	query := `
+        SELECT ` + deletedTransactionColumns + `
+        FROM "Transactions" t
+        LEFT JOIN "TransactionCategories" cat ON cat."Id" = t."CategoryId"
+        WHERE t."Id" = $1 AND t."DeletedAt" IS NOT NULL LIMIT 1`
+	return scanDeletedTransaction(repo.db.QueryRow(query, id))
+}
+
+// GetDeletedTransactions lists the trash of the ledger, most recently deleted
+// first.
+func (repo *transactionRepository) GetDeletedTransactions(ledgerId int64) ([]model.Transaction, error) {
+	transactions := []model.Transaction{}
+	query := `
+        SELECT ` + deletedTransactionColumns + `
+        FROM "Transactions" t
+        LEFT JOIN "TransactionCategories" cat ON cat."Id" = t."CategoryId"
+        WHERE t."LedgerId" = $1 AND t."DeletedAt" IS NOT NULL
+        ORDER BY t."DeletedAt" DESC, t."Id" DESC`
+	rows, err := repo.db.Query(query, ledgerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanDeletedTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, item)
+	}
+
+	return transactions, rows.Err()
+}
+
+// PurgeDeletedTransactions removes transactions deleted before the given time
+// for good, together with their history, as their ids may be given to new
+// transactions. It has to run inside a transaction, see WithTx.
+func (repo *transactionRepository) PurgeDeletedTransactions(before time.Time) (int64, error) {
+	queries := []string{
+		`DELETE FROM "TransactionHistory" WHERE "TransactionId" IN (SELECT "Id" FROM "Transactions" WHERE "DeletedAt" < $1)`,
+		`DELETE FROM "TransactionSplits" WHERE "TransactionId" IN (SELECT "Id" FROM "Transactions" WHERE "DeletedAt" < $1)`,
+		`DELETE FROM "TransactionTags" WHERE "TransactionId" IN (SELECT "Id" FROM "Transactions" WHERE "DeletedAt" < $1)`,
+	}
+	for _, query := range queries {
+		if _, err := repo.db.Exec(query, formatSqlTime(before)); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := repo.db.Exec(`DELETE FROM "Transactions" WHERE "DeletedAt" < $1`, formatSqlTime(before))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpdateTransaction stores the transaction and records what changed in its
+// history. It has to run inside a transaction, see WithTx.
+func (repo *transactionRepository) UpdateTransaction(transaction model.Transaction, userId int64) error {
+	date, err := utils.ParseDate(transaction.Date)
+	if err != nil {
+		return err
+	}
+
+	old, err := repo.GetTransactionById(transaction.Id)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE "Transactions"
+        SET "Kind" = $1, "Price" = $2, "Currency" = $3, "AccountId" = $4, "Date" = $5, "Description" = $6, "Merchant" = $7, "Notes" = $8, "CategoryId" = NULLIF($9, 0)
+        WHERE "Id" = $10`
+	_, err = repo.db.Exec(query, transaction.Kind, transaction.Price, transaction.Currency, transaction.AccountId, formatSqlTime(date), transaction.Description, transaction.Merchant, transaction.Notes, transaction.CategoryId, transaction.Id)
+	if err != nil {
+		return err
+	}
+
+	updated, err := repo.GetTransactionById(transaction.Id)
+	if err != nil {
+		return err
+	}
+	changes := diffTransactions(&old, &updated)
+	if len(changes) == 0 {
+		return nil
+	}
+	return repo.addHistory(transaction.Id, updated.LedgerId, userId, model.TransactionActionUpdate, changes)
+}
+
+func (repo *transactionRepository) GetTotalPriceByDateAndCategory(ledgerId int64, filter TotalFilter) (model.TransactionTotal, error) {
+	total := model.TransactionTotal{Currency: filter.ConvertTo}
 
 	counter := utils.IncreasingCounter{}
+	args := []interface{}{}
+
+	// The price placeholder goes first, because placeholders have to be
+	// numbered in the order they appear in the query.
+	price := `"Price"`
+	if filter.ConvertTo != "" {
+		price = convertedPriceSql("$" + strconv.Itoa(counter.Next()))
+		args = append(args, filter.ConvertTo)
+	}
+
 	conditions := []string{
-		`"UserId" = $` + fmt.Sprintf("%d", counter.Next()),
-		`strftime('%Y', "CreatedAt") = $` + fmt.Sprintf("%d", counter.Next()),
+		`"DeletedAt" IS NULL`,
+		`"LedgerId" = $` + fmt.Sprintf("%d", counter.Next()),
+		`strftime('%Y', "Date") = $` + fmt.Sprintf("%d", counter.Next()),
+	}
+	args = append(args, ledgerId, fmt.Sprintf("%04d", filter.Year))
+
+	if filter.CategoryId != 0 {
+		condition, categoryArgs := categorySubtreeSql([]int64{filter.CategoryId}, &counter)
+		conditions = append(conditions, condition)
+		args = append(args, categoryArgs...)
+	}
+
+	if len(filter.TagIds) > 0 {
+		condition, tagArgs := tagFilterSql(`"Id"`, filter.TagIds, false, &counter)
+		conditions = append(conditions, condition)
+		args = append(args, tagArgs...)
 	}
-	args := []interface{}{userId, fmt.Sprintf("%04d", year)}
 
-	if categoryId != 0 {
-		conditions = append(conditions, `"CategoryId" = $`+fmt.Sprintf("%d", counter.Next()))
-		args = append(args, categoryId)
+	if len(filter.ExcludeTagIds) > 0 {
+		condition, tagArgs := tagFilterSql(`"Id"`, filter.ExcludeTagIds, true, &counter)
+		conditions = append(conditions, condition)
+		args = append(args, tagArgs...)
 	}
 
-	if month != 0 {
-		conditions = append(conditions, `strftime('%m', "CreatedAt") = $`+fmt.Sprintf("%d", counter.Next()))
-		args = append(args, fmt.Sprintf("%02d", month))
-		if day != 0 {
-			conditions = append(conditions, `strftime('%d', "CreatedAt") = $`+fmt.Sprintf("%d", counter.Next()))
-			args = append(args, fmt.Sprintf("%02d", day))
+	if filter.Month != 0 {
+		conditions = append(conditions, `strftime('%m', "Date") = $`+fmt.Sprintf("%d", counter.Next()))
+		args = append(args, fmt.Sprintf("%02d", filter.Month))
+		if filter.Day != 0 {
+			conditions = append(conditions, `strftime('%d', "Date") = $`+fmt.Sprintf("%d", counter.Next()))
+			args = append(args, fmt.Sprintf("%02d", filter.Day))
 		}
 	}
 
-	query := fmt.Sprintf(`SELECT COALESCE(SUM("Price"), 0) FROM "Transactions" WHERE %s`, strings.Join(conditions, " AND "))
+	// Transfers only move money between accounts, so they count neither as
+	// income nor as expense.
+	query := fmt.Sprintf(`
+        SELECT
+            COALESCE(SUM(CASE WHEN "Kind" = 'income' THEN %[1]s END), 0),
+            COALESCE(SUM(CASE WHEN "Kind" = 'expense' THEN %[1]s END), 0),
+            COUNT(CASE WHEN "Kind" IN ('income', 'expense') AND %[1]s IS NULL THEN 1 END)
+        FROM "Transactions" WHERE %[2]s`, price, strings.Join(conditions, " AND "))
 
-	err := repo.db.QueryRow(query, args...).Scan(&totalPrice)
+	err := repo.db.QueryRow(query, args...).Scan(&total.Income, &total.Expense, &total.MissingRates)
 	if err != nil {
-		return 0, err
+		return model.TransactionTotal{}, err
+	}
+
+	total.TotalPrice = total.Expense
+	total.Net = total.Income - total.Expense
+	return total, nil
+}
+
+// statsPeriodSql maps every grouping to an expression with the first day of
+// the period of a "Date".
+var statsPeriodSql = map[string]string{
+	StatsGroupByDay:   `date("Date")`,
+	StatsGroupByWeek:  `date("Date", 'weekday 0', '-6 days')`,
+	StatsGroupByMonth: `strftime('%Y-%m-01', "Date")`,
+	StatsGroupByYear:  `strftime('%Y-01-01', "Date")`,
+}
+
+func IsValidStatsGroupBy(groupBy string) bool {
+	_, ok := statsPeriodSql[groupBy]
+	return ok
+}
+
+// StatsPeriodStart returns the first day of the period date falls into.
+func StatsPeriodStart(date time.Time, groupBy string) time.Time {
+	year, month, day := date.Date()
+	switch groupBy {
+	case StatsGroupByWeek:
+		weekday := (int(date.Weekday()) + 6) % 7
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, time.UTC)
+	case StatsGroupByMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case StatsGroupByYear:
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// StatsNextPeriod returns the first day of the period after the one starting
+// at start.
+func StatsNextPeriod(start time.Time, groupBy string) time.Time {
+	switch groupBy {
+	case StatsGroupByWeek:
+		return start.AddDate(0, 0, 7)
+	case StatsGroupByMonth:
+		return start.AddDate(0, 1, 0)
+	case StatsGroupByYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// GetTransactionStats sums income and expense for every period between From
+// and To, and per category too when asked. Periods and categories without
+// transactions are filled with zeros.
+func (repo *transactionRepository) GetTransactionStats(ledgerId int64, filter StatsFilter) (model.TransactionStats, error) {
+	stats := model.TransactionStats{GroupBy: filter.GroupBy, Currency: filter.ConvertTo, Buckets: []model.TransactionStatsBucket{}}
+
+	periodSql, ok := statsPeriodSql[filter.GroupBy]
+	if !ok {
+		return stats, fmt.Errorf("unknown grouping %q", filter.GroupBy)
+	}
+
+	counter := utils.IncreasingCounter{}
+	args := []interface{}{}
+
+	price := `"Price"`
+	if filter.ConvertTo != "" {
+		price = convertedPriceSql("$" + strconv.Itoa(counter.Next()))
+		args = append(args, filter.ConvertTo)
+	}
+
+	categorySql := "0"
+	if filter.ByCategory {
+		categorySql = `COALESCE("CategoryId", 0)`
+	}
+
+	conditions := []string{
+		`"DeletedAt" IS NULL`,
+		`"LedgerId" = $` + strconv.Itoa(counter.Next()),
+		`"Kind" IN ('income', 'expense')`,
+		`"Date" >= $` + strconv.Itoa(counter.Next()),
+		`"Date" < $` + strconv.Itoa(counter.Next()),
+	}
+	args = append(args, ledgerId, formatSqlTime(filter.From), formatSqlTime(filter.To))
+
+	if len(filter.CategoryIds) > 0 {
+		condition, categoryArgs := categorySubtreeSql(filter.CategoryIds, &counter)
+		conditions = append(conditions, condition)
+		args = append(args, categoryArgs...)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT %[2]s AS "Period", %[3]s AS "Category",
+            COALESCE(SUM(CASE WHEN "Kind" = 'income' THEN %[1]s END), 0),
+            COALESCE(SUM(CASE WHEN "Kind" = 'expense' THEN %[1]s END), 0),
+            COUNT(CASE WHEN %[1]s IS NULL THEN 1 END)
+        FROM "Transactions" WHERE %[4]s
+        GROUP BY "Period", "Category"`, price, periodSql, categorySql, strings.Join(conditions, " AND "))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	sums := map[string]map[int64]*model.TransactionStatsCategory{}
+	categoryIds := []int64{}
+	seenCategories := map[int64]bool{}
+	for rows.Next() {
+		var period string
+		var sum model.TransactionStatsCategory
+		var missingRates int64
+		if err := rows.Scan(&period, &sum.CategoryId, &sum.Income, &sum.Expense, &missingRates); err != nil {
+			return stats, err
+		}
+		stats.MissingRates += missingRates
+
+		if sums[period] == nil {
+			sums[period] = map[int64]*model.TransactionStatsCategory{}
+		}
+		sums[period][sum.CategoryId] = &sum
+
+		if !seenCategories[sum.CategoryId] {
+			seenCategories[sum.CategoryId] = true
+			categoryIds = append(categoryIds, sum.CategoryId)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	if filter.ByCategory {
+		for _, categoryId := range filter.CategoryIds {
+			if !seenCategories[categoryId] {
+				seenCategories[categoryId] = true
+				categoryIds = append(categoryIds, categoryId)
+			}
+		}
+		slices.Sort(categoryIds)
+	}
+
+	for start := StatsPeriodStart(filter.From, filter.GroupBy); start.Before(filter.To); start = StatsNextPeriod(start, filter.GroupBy) {
+		period := start.Format("2006-01-02")
+		bucket := model.TransactionStatsBucket{Period: period}
+		if filter.ByCategory {
+			bucket.Categories = []model.TransactionStatsCategory{}
+		}
+
+		for _, categoryId := range categoryIds {
+			sum := model.TransactionStatsCategory{CategoryId: categoryId}
+			if found := sums[period][categoryId]; found != nil {
+				sum = *found
+			}
+			sum.Net = sum.Income - sum.Expense
+
+			bucket.Income += sum.Income
+			bucket.Expense += sum.Expense
+			if filter.ByCategory {
+				bucket.Categories = append(bucket.Categories, sum)
+			}
+		}
+		bucket.Net = bucket.Income - bucket.Expense
+
+		stats.Buckets = append(stats.Buckets, bucket)
+	}
+
+	return stats, nil
+}
+
+// SetSplits replaces the splits of a transaction, no splits means the payer
+// keeps the whole price. It has to run inside a transaction, see WithTx.
+func (repo *transactionRepository) SetSplits(transactionId int64, splits []model.TransactionSplit) error {
+	if _, err := repo.db.Exec(`DELETE FROM "TransactionSplits" WHERE "TransactionId" = $1`, transactionId); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO "TransactionSplits" ("TransactionId", "UserId", "Amount") VALUES ($1, $2, $3)`
+	for _, split := range splits {
+		if _, err := repo.db.Exec(query, transactionId, split.UserId, split.Amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *transactionRepository) GetSplits(transactionIds []int64) (map[int64][]model.TransactionSplit, error) {
+	splits := map[int64][]model.TransactionSplit{}
+	if len(transactionIds) == 0 {
+		return splits, nil
+	}
+
+	placeholders := make([]string, len(transactionIds))
+	args := make([]interface{}, len(transactionIds))
+	for i, id := range transactionIds {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		args[i] = id
+	}
+
+	query := `
+        SELECT "TransactionId", "UserId", "Amount" FROM "TransactionSplits"
+        WHERE "TransactionId" IN (` + strings.Join(placeholders, ", ") + `)
+        ORDER BY "TransactionId", "UserId"`
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionId int64
+		var split model.TransactionSplit
+		if err := rows.Scan(&transactionId, &split.UserId, &split.Amount); err != nil {
+			return nil, err
+		}
+		splits[transactionId] = append(splits[transactionId], split)
+	}
+
+	return splits, rows.Err()
+}
+
+// GetBalances sums what every member of the ledger is owed in each currency.
+// The payer of a split transaction is owed every split and every member owes
+// their own split, so the payer's own share cancels out.
+func (repo *transactionRepository) GetBalances(ledgerId int64) ([]model.Balance, error) {
+	balances := []model.Balance{}
+	query := `
+        SELECT b."UserId", COALESCE(u."Login", ''), b."Currency", SUM(b."Amount")
+        FROM (
+            SELECT t."UserId", t."Currency", s."Amount"
+            FROM "TransactionSplits" s JOIN "Transactions" t ON t."Id" = s."TransactionId"
+            WHERE t."LedgerId" = $1 AND t."DeletedAt" IS NULL
+            UNION ALL
+            SELECT s."UserId", t."Currency", -s."Amount"
+            FROM "TransactionSplits" s JOIN "Transactions" t ON t."Id" = s."TransactionId"
+            WHERE t."LedgerId" = $1 AND t."DeletedAt" IS NULL
+        ) AS b
+        LEFT JOIN "Users" u ON u."Id" = b."UserId"
+        GROUP BY b."UserId", b."Currency"
+        HAVING SUM(b."Amount") != 0
+        ORDER BY b."Currency", b."UserId"`
+	rows, err := repo.db.Query(query, ledgerId)
+	if err != nil {
+		return balances, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var balance model.Balance
+		if err := rows.Scan(&balance.UserId, &balance.Login, &balance.Currency, &balance.Amount); err != nil {
+			return balances, err
+		}
+		balances = append(balances, balance)
 	}
 
-	return totalPrice, nil
+	return balances, rows.Err()
 }
@@ -2,43 +2,61 @@ package repository
 
 import (
 	"database/sql"
-	"errors"
 	"expenses_tracker/internal/model"
 )
 
 type UserRepository interface {
-	Create(user model.UserModel) error
+	WithTx(tx *sql.Tx) UserRepository
+	Create(user model.UserModel) (int64, error)
 	FindByLogin(login string) (model.UserModel, error)
 	FindById(id int64) (model.UserModel, error)
+	UpdateBaseCurrency(id int64, currency string) error
+	UpdateDefaultLedger(id int64, ledgerId int64) error
 }
 
 type userRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func GetUserRepository(db *sql.DB) *userRepository {
 	return &userRepository{db: db}
 }
 
-func (repo *userRepository) Create(user model.UserModel) error {
-	query := `INSERT INTO "Users" ("Login", "PasswordHash") VALUES ($1, $2)`
-	err := repo.db.QueryRow(query, user.Login, user.PasswordHash).Scan()
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil
+func (repo *userRepository) WithTx(tx *sql.Tx) UserRepository {
+	return &userRepository{db: tx}
+}
+
+func (repo *userRepository) Create(user model.UserModel) (int64, error) {
+	query := `INSERT INTO "Users" ("Login", "PasswordHash", "BaseCurrency") VALUES ($1, $2, $3)`
+	result, err := repo.db.Exec(query, user.Login, user.PasswordHash, user.BaseCurrency)
+	if err != nil {
+		return 0, err
 	}
-	return err
+	return result.LastInsertId()
 }
 
 func (repo *userRepository) FindByLogin(login string) (model.UserModel, error) {
 	var user model.UserModel
-	query := `SELECT "Id", "Login", "PasswordHash" FROM "Users" WHERE "Login" = $1`
-	err := repo.db.QueryRow(query, login).Scan(&user.Id, &user.Login, &user.PasswordHash)
+	query := `SELECT "Id", "Login", "PasswordHash", "BaseCurrency", COALESCE("DefaultLedgerId", 0) FROM "Users" WHERE "Login" = $1`
+	err := repo.db.QueryRow(query, login).Scan(&user.Id, &user.Login, &user.PasswordHash, &user.BaseCurrency, &user.DefaultLedgerId)
 	return user, err
 }
 
 func (repo *userRepository) FindById(id int64) (model.UserModel, error) {
 	var user model.UserModel
-	query := `SELECT "Id", "Login", "PasswordHash" FROM "Users" WHERE "Id" = $1`
-	err := repo.db.QueryRow(query, id).Scan(&user.Id, &user.Login, &user.PasswordHash)
+	query := `SELECT "Id", "Login", "PasswordHash", "BaseCurrency", COALESCE("DefaultLedgerId", 0) FROM "Users" WHERE "Id" = $1`
+	err := repo.db.QueryRow(query, id).Scan(&user.Id, &user.Login, &user.PasswordHash, &user.BaseCurrency, &user.DefaultLedgerId)
 	return user, err
 }
+
+func (repo *userRepository) UpdateBaseCurrency(id int64, currency string) error {
+	query := `UPDATE "Users" SET "BaseCurrency" = $1 WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, currency, id)
+	return err
+}
+
+func (repo *userRepository) UpdateDefaultLedger(id int64, ledgerId int64) error {
+	query := `UPDATE "Users" SET "DefaultLedgerId" = $1 WHERE "Id" = $2`
+	_, err := repo.db.Exec(query, ledgerId, id)
+	return err
+}
@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/schedule"
+	"expenses_tracker/internal/repository"
+	"log"
+	"time"
+)
+
+// maxOccurrencesPerRun bounds how many occurrences of one template are posted
+// in a single run, the rest is picked up by the next runs.
+const maxOccurrencesPerRun = 1000
+
+var errAlreadyPosted = errors.New("occurrence is already handled")
+
+// RecurringWorker periodically posts due occurrences of recurring
+// transactions. Every posted occurrence is recorded in the same database
+// transaction as the transaction itself, so restarts never post twice.
+type RecurringWorker struct {
+	transactor                     repository.Transactor
+	recurringTransactionRepository repository.RecurringTransactionRepository
+	transactionRepository          repository.TransactionRepository
+	interval                       time.Duration
+}
+
+func GetRecurringWorker(transactor repository.Transactor, recurringTransactionRepository repository.RecurringTransactionRepository, transactionRepository repository.TransactionRepository, interval time.Duration) *RecurringWorker {
+	return &RecurringWorker{
+		transactor:                     transactor,
+		recurringTransactionRepository: recurringTransactionRepository,
+		transactionRepository:          transactionRepository,
+		interval:                       interval,
+	}
+}
+
+func (w *RecurringWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.PostDue(time.Now()); err != nil {
+			log.Println("recurring transactions:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PostDue posts every not yet handled occurrence up to the day of now.
+func (w *RecurringWorker) PostDue(now time.Time) error {
+	today := schedule.Today(now)
+	recurringTransactions, err := w.recurringTransactionRepository.GetStartedRecurringTransactions(today.Format(schedule.DateLayout))
+	if err != nil {
+		return err
+	}
+
+	for _, recurring := range recurringTransactions {
+		if err := w.postRecurringTransaction(recurring, today); err != nil {
+			log.Printf("recurring transaction %d: %v", recurring.Id, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *RecurringWorker) postRecurringTransaction(recurring model.RecurringTransaction, today time.Time) error {
+	rule, err := schedule.NewRule(recurring.Frequency, recurring.Interval, recurring.StartDate, recurring.EndDate)
+	if err != nil {
+		return err
+	}
+
+	handled, err := w.recurringTransactionRepository.GetOccurrences(recurring.Id)
+	if err != nil {
+		return err
+	}
+
+	// Occurrences are posted in order, so everything before the last posted
+	// one is handled already.
+	start := 0
+	last := ""
+	for day, status := range handled {
+		if status == model.OccurrenceStatusPosted && day > last {
+			last = day
+		}
+	}
+	if last != "" {
+		lastDate, err := schedule.ParseDate(last)
+		if err != nil {
+			return err
+		}
+		start = rule.Index(lastDate)
+	}
+
+	posted := 0
+	for n := start; posted < maxOccurrencesPerRun; n++ {
+		date := rule.Occurrence(n)
+		if date.After(today) || (rule.End != nil && date.After(*rule.End)) {
+			break
+		}
+
+		day := date.Format(schedule.DateLayout)
+		if _, ok := handled[day]; ok {
+			continue
+		}
+
+		err := w.transactor.InTransaction(func(tx *sql.Tx) error {
+			transactionId, err := w.transactionRepository.WithTx(tx).CreateTransaction(model.Transaction{
+				Kind:        recurring.Kind,
+				Price:       recurring.Price,
+				Currency:    recurring.Currency,
+				CategoryId:  recurring.CategoryId,
+				AccountId:   recurring.AccountId,
+				Date:        day,
+				Description: recurring.Description,
+				Merchant:    recurring.Merchant,
+				UserId:      recurring.UserId,
+			})
+			if err != nil {
+				return err
+			}
+
+			added, err := w.recurringTransactionRepository.WithTx(tx).AddOccurrence(recurring.Id, day, model.OccurrenceStatusPosted, &transactionId)
+			if err != nil {
+				return err
+			}
+			if !added {
+				return errAlreadyPosted
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errAlreadyPosted) {
+			return err
+		}
+		posted++
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"database/sql"
+	"expenses_tracker/internal/model"
+	"expenses_tracker/internal/pkg/schedule"
+	"expenses_tracker/internal/repository"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) InTransaction(fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
+
+// fakeRecurringRepository keeps the templates and their occurrences in
+// memory. Methods the worker doesn't use panic through the nil interface.
+type fakeRecurringRepository struct {
+	repository.RecurringTransactionRepository
+	recurring   []model.RecurringTransaction
+	occurrences map[int64]map[string]string
+}
+
+func (repo *fakeRecurringRepository) WithTx(tx *sql.Tx) repository.RecurringTransactionRepository {
+	return repo
+}
+
+func (repo *fakeRecurringRepository) GetStartedRecurringTransactions(date string) ([]model.RecurringTransaction, error) {
+	started := []model.RecurringTransaction{}
+	for _, recurring := range repo.recurring {
+		if recurring.StartDate <= date {
+			started = append(started, recurring)
+		}
+	}
+	return started, nil
+}
+
+func (repo *fakeRecurringRepository) GetOccurrences(recurringId int64) (map[string]string, error) {
+	handled := map[string]string{}
+	for day, status := range repo.occurrences[recurringId] {
+		handled[day] = status
+	}
+	return handled, nil
+}
+
+func (repo *fakeRecurringRepository) AddOccurrence(recurringId int64, date string, status string, transactionId *int64) (bool, error) {
+	if repo.occurrences[recurringId] == nil {
+		repo.occurrences[recurringId] = map[string]string{}
+	}
+	if _, ok := repo.occurrences[recurringId][date]; ok {
+		return false, nil
+	}
+	repo.occurrences[recurringId][date] = status
+	return true, nil
+}
+
+type fakeTransactionRepository struct {
+	repository.TransactionRepository
+	created []model.Transaction
+}
+
+func (repo *fakeTransactionRepository) WithTx(tx *sql.Tx) repository.TransactionRepository {
+	return repo
+}
+
+func (repo *fakeTransactionRepository) CreateTransaction(transaction model.Transaction) (int64, error) {
+	repo.created = append(repo.created, transaction)
+	return int64(len(repo.created)), nil
+}
+
+func TestPostDue(t *testing.T) {
+	endDate := "2024-03-31"
+	tests := []struct {
+		name      string
+		recurring model.RecurringTransaction
+		handled   map[string]string
+		now       string
+		want      []string
+	}{
+		{
+			name:      "month ends",
+			recurring: model.RecurringTransaction{Frequency: schedule.Monthly, Interval: 1, StartDate: "2024-01-31"},
+			now:       "2024-04-15",
+			want:      []string{"2024-01-31", "2024-02-29", "2024-03-31"},
+		},
+		{
+			name:      "interval",
+			recurring: model.RecurringTransaction{Frequency: schedule.Weekly, Interval: 2, StartDate: "2024-01-01"},
+			now:       "2024-02-01",
+			want:      []string{"2024-01-01", "2024-01-15", "2024-01-29"},
+		},
+		{
+			name:      "end date",
+			recurring: model.RecurringTransaction{Frequency: schedule.Monthly, Interval: 1, StartDate: "2024-01-15", EndDate: &endDate},
+			now:       "2024-12-31",
+			want:      []string{"2024-01-15", "2024-02-15", "2024-03-15"},
+		},
+		{
+			name:      "not started",
+			recurring: model.RecurringTransaction{Frequency: schedule.Daily, Interval: 1, StartDate: "2024-05-01"},
+			now:       "2024-04-30",
+			want:      []string{},
+		},
+		{
+			name:      "skipped occurrence",
+			recurring: model.RecurringTransaction{Frequency: schedule.Monthly, Interval: 1, StartDate: "2024-01-10"},
+			handled:   map[string]string{"2024-02-10": model.OccurrenceStatusSkipped},
+			now:       "2024-03-10",
+			want:      []string{"2024-01-10", "2024-03-10"},
+		},
+		{
+			name:      "continues after the last posted",
+			recurring: model.RecurringTransaction{Frequency: schedule.Daily, Interval: 1, StartDate: "2024-01-01"},
+			handled:   map[string]string{"2024-01-03": model.OccurrenceStatusPosted},
+			now:       "2024-01-05",
+			want:      []string{"2024-01-04", "2024-01-05"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.recurring.Id = 1
+			tt.recurring.Price = 100
+			recurringRepository := &fakeRecurringRepository{
+				recurring:   []model.RecurringTransaction{tt.recurring},
+				occurrences: map[int64]map[string]string{1: tt.handled},
+			}
+			transactionRepository := &fakeTransactionRepository{}
+			w := GetRecurringWorker(fakeTransactor{}, recurringRepository, transactionRepository, time.Hour)
+
+			now, err := schedule.ParseDate(tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Later in the day than the last occurrence, which is still due.
+			now = now.Add(18 * time.Hour)
+
+			if err := w.PostDue(now); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, transaction := range transactionRepository.created {
+				if transaction.Price != tt.recurring.Price {
+					t.Errorf("posted price %d, want %d", transaction.Price, tt.recurring.Price)
+				}
+				got = append(got, transaction.Date)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("posted %v, want %v", got, tt.want)
+			}
+
+			if err := w.PostDue(now); err != nil {
+				t.Fatal(err)
+			}
+			if len(transactionRepository.created) != len(tt.want) {
+				t.Errorf("second run posted %v", transactionRepository.created[len(tt.want):])
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"expenses_tracker/internal/pkg/storage"
+	"expenses_tracker/internal/repository"
+	"log"
+	"time"
+)
+
+// TrashWorker periodically purges transactions and categories that have been
+// in the trash for longer than the retention period, along with the
+// attachments of those transactions.
+type TrashWorker struct {
+	transactor                    repository.Transactor
+	transactionRepository         repository.TransactionRepository
+	transactionCategoryRepository repository.TransactionCategoryRepository
+	attachmentRepository          repository.AttachmentRepository
+	storage                       storage.Storage
+	retention                     time.Duration
+	interval                      time.Duration
+}
+
+func GetTrashWorker(transactor repository.Transactor, transactionRepository repository.TransactionRepository, transactionCategoryRepository repository.TransactionCategoryRepository, attachmentRepository repository.AttachmentRepository, fileStorage storage.Storage, retention time.Duration, interval time.Duration) *TrashWorker {
+	return &TrashWorker{
+		transactor:                    transactor,
+		transactionRepository:         transactionRepository,
+		transactionCategoryRepository: transactionCategoryRepository,
+		attachmentRepository:          attachmentRepository,
+		storage:                       fileStorage,
+		retention:                     retention,
+		interval:                      interval,
+	}
+}
+
+func (w *TrashWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Purge(time.Now()); err != nil {
+			log.Println("trash:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes everything deleted before now minus the retention period.
+func (w *TrashWorker) Purge(now time.Time) error {
+	before := now.Add(-w.retention)
+
+	var hashes []string
+	err := w.transactor.InTransaction(func(tx *sql.Tx) error {
+		var err error
+		hashes, err = w.attachmentRepository.WithTx(tx).PurgeDeletedTransactionAttachments(before)
+		if err != nil {
+			return err
+		}
+
+		transactions, err := w.transactionRepository.WithTx(tx).PurgeDeletedTransactions(before)
+		if err != nil {
+			return err
+		}
+
+		categories, err := w.transactionCategoryRepository.WithTx(tx).PurgeDeletedCategories(before)
+		if err != nil {
+			return err
+		}
+
+		if transactions > 0 || categories > 0 {
+			log.Printf("trash: purged %d transactions and %d categories", transactions, categories)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The files go only after the rows are gone for good, and only those no
+	// other attachment points at.
+	for _, hash := range hashes {
+		if err := w.deleteUnusedFile(hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUnusedFile holds the storage lock of the file, so an upload of the
+// same file can't start using it between the check and the delete.
+func (w *TrashWorker) deleteUnusedFile(hash string) error {
+	unlock := w.storage.Lock(hash)
+	defer unlock()
+
+	used, err := w.attachmentRepository.IsHashUsed(hash)
+	if err != nil || used {
+		return err
+	}
+	return w.storage.Delete(hash)
+}
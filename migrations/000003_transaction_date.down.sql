@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "Transactions_UserId_Date";
+
+ALTER TABLE "Transactions" DROP COLUMN "Date";
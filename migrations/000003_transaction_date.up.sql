@@ -0,0 +1,5 @@
+ALTER TABLE "Transactions" ADD COLUMN "Date" TIMESTAMP;
+
+UPDATE "Transactions" SET "Date" = "CreatedAt";
+
+CREATE INDEX "Transactions_UserId_Date" ON "Transactions" ("UserId", "Date");
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS "Transactions_Search_Insert";
+DROP TRIGGER IF EXISTS "Transactions_Search_BeforeUpdate";
+DROP TRIGGER IF EXISTS "Transactions_Search_AfterUpdate";
+DROP TRIGGER IF EXISTS "Transactions_Search_Delete";
+DROP TABLE IF EXISTS "TransactionsSearch";
+
+ALTER TABLE "Transactions" DROP COLUMN "Notes";
+ALTER TABLE "Transactions" DROP COLUMN "Merchant";
+ALTER TABLE "Transactions" DROP COLUMN "Description";
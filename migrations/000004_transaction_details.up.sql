@@ -0,0 +1,32 @@
+ALTER TABLE "Transactions" ADD COLUMN "Description" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "Transactions" ADD COLUMN "Merchant" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "Transactions" ADD COLUMN "Notes" TEXT NOT NULL DEFAULT '';
+
+-- FTS4 instead of FTS5: go-sqlite3 only compiles FTS5 in with the sqlite_fts5 build tag.
+CREATE VIRTUAL TABLE "TransactionsSearch" USING fts4(
+    content="Transactions",
+    "Description",
+    "Merchant",
+    "Notes",
+    tokenize=unicode61
+);
+
+INSERT INTO "TransactionsSearch" ("TransactionsSearch") VALUES ('rebuild');
+
+CREATE TRIGGER "Transactions_Search_Insert" AFTER INSERT ON "Transactions" BEGIN
+    INSERT INTO "TransactionsSearch" (docid, "Description", "Merchant", "Notes")
+    VALUES (new."Id", new."Description", new."Merchant", new."Notes");
+END;
+
+CREATE TRIGGER "Transactions_Search_BeforeUpdate" BEFORE UPDATE ON "Transactions" BEGIN
+    DELETE FROM "TransactionsSearch" WHERE docid = old."Id";
+END;
+
+CREATE TRIGGER "Transactions_Search_AfterUpdate" AFTER UPDATE ON "Transactions" BEGIN
+    INSERT INTO "TransactionsSearch" (docid, "Description", "Merchant", "Notes")
+    VALUES (new."Id", new."Description", new."Merchant", new."Notes");
+END;
+
+CREATE TRIGGER "Transactions_Search_Delete" BEFORE DELETE ON "Transactions" BEGIN
+    DELETE FROM "TransactionsSearch" WHERE docid = old."Id";
+END;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "ExchangeRates";
+
+ALTER TABLE "Users" DROP COLUMN "BaseCurrency";
+
+ALTER TABLE "Transactions" DROP COLUMN "Currency";
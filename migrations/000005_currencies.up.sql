@@ -0,0 +1,13 @@
+ALTER TABLE "Transactions" ADD COLUMN "Currency" TEXT NOT NULL DEFAULT 'USD';
+
+ALTER TABLE "Users" ADD COLUMN "BaseCurrency" TEXT NOT NULL DEFAULT 'USD';
+
+-- One "FromCurrency" is worth "Rate" of "ToCurrency" starting from "Date".
+CREATE TABLE "ExchangeRates" (
+    "Id" INTEGER PRIMARY KEY,
+    "Date" TEXT NOT NULL,
+    "FromCurrency" TEXT NOT NULL,
+    "ToCurrency" TEXT NOT NULL,
+    "Rate" REAL NOT NULL,
+    UNIQUE ("FromCurrency", "ToCurrency", "Date")
+);
@@ -0,0 +1 @@
+ALTER TABLE "Transactions" ADD COLUMN "Kind" TEXT NOT NULL DEFAULT 'expense';
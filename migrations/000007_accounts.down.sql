@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "Transactions_AccountId_Date";
+
+ALTER TABLE "Transactions" DROP COLUMN "TransferId";
+ALTER TABLE "Transactions" DROP COLUMN "AccountId";
+
+DROP TABLE IF EXISTS "Accounts";
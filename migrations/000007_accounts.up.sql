@@ -0,0 +1,17 @@
+CREATE TABLE "Accounts" (
+    "Id" INTEGER PRIMARY KEY,
+    "UserId" INTEGER NOT NULL,
+    "Name" TEXT NOT NULL,
+    "Type" TEXT NOT NULL,
+    "Currency" TEXT NOT NULL,
+    "OpeningBalance" INTEGER NOT NULL DEFAULT 0,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id")
+);
+
+ALTER TABLE "Transactions" ADD COLUMN "AccountId" INTEGER REFERENCES "Accounts"("Id");
+
+-- Both sides of a transfer share the id of its outgoing transaction.
+ALTER TABLE "Transactions" ADD COLUMN "TransferId" INTEGER;
+
+CREATE INDEX "Transactions_AccountId_Date" ON "Transactions" ("AccountId", "Date");
@@ -0,0 +1,12 @@
+CREATE TABLE "Budgets" (
+    "Id" INTEGER PRIMARY KEY,
+    "UserId" INTEGER NOT NULL,
+    "CategoryId" INTEGER NOT NULL,
+    "Year" INTEGER NOT NULL,
+    "Month" INTEGER NOT NULL,
+    "Amount" INTEGER NOT NULL,
+    "Rollover" BOOLEAN NOT NULL DEFAULT FALSE, -- Unspent amount moves to the next month
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id"),
+    FOREIGN KEY ("CategoryId") REFERENCES "TransactionCategories"("Id"),
+    UNIQUE ("UserId", "CategoryId", "Year", "Month")
+);
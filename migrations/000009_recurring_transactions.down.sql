@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "RecurringOccurrences";
+DROP TABLE IF EXISTS "RecurringTransactions";
@@ -0,0 +1,30 @@
+CREATE TABLE "RecurringTransactions" (
+    "Id" INTEGER PRIMARY KEY,
+    "UserId" INTEGER NOT NULL,
+    "CategoryId" INTEGER NOT NULL,
+    "AccountId" INTEGER,
+    "Kind" TEXT NOT NULL DEFAULT 'expense',
+    "Price" INTEGER NOT NULL,
+    "Currency" TEXT NOT NULL,
+    "Description" TEXT NOT NULL DEFAULT '',
+    "Merchant" TEXT NOT NULL DEFAULT '',
+    "Frequency" TEXT NOT NULL, -- daily, weekly, monthly or yearly
+    "Interval" INTEGER NOT NULL DEFAULT 1,
+    "StartDate" TEXT NOT NULL, -- YYYY-MM-DD
+    "EndDate" TEXT,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id"),
+    FOREIGN KEY ("CategoryId") REFERENCES "TransactionCategories"("Id"),
+    FOREIGN KEY ("AccountId") REFERENCES "Accounts"("Id")
+);
+
+-- One row per handled occurrence, so an occurrence is never posted twice.
+CREATE TABLE "RecurringOccurrences" (
+    "RecurringTransactionId" INTEGER NOT NULL,
+    "Date" TEXT NOT NULL,
+    "Status" TEXT NOT NULL, -- posted or skipped
+    "TransactionId" INTEGER,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("RecurringTransactionId", "Date"),
+    FOREIGN KEY ("RecurringTransactionId") REFERENCES "RecurringTransactions"("Id")
+);
@@ -0,0 +1,23 @@
+-- A session is one login on one device, its id is the jti of access tokens.
+CREATE TABLE "Sessions" (
+    "Id" TEXT PRIMARY KEY,
+    "UserId" INTEGER NOT NULL,
+    "UserAgent" TEXT NOT NULL DEFAULT '',
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "LastUsedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "ExpiresAt" TIMESTAMP NOT NULL,
+    "RevokedAt" TIMESTAMP,
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id")
+);
+
+CREATE INDEX "Sessions_UserId" ON "Sessions" ("UserId");
+
+-- Every refresh token ever issued, stored as a SHA-256 hash. Used tokens are
+-- kept to detect their reuse.
+CREATE TABLE "RefreshTokens" (
+    "TokenHash" TEXT PRIMARY KEY,
+    "SessionId" TEXT NOT NULL,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "UsedAt" TIMESTAMP,
+    FOREIGN KEY ("SessionId") REFERENCES "Sessions"("Id")
+);
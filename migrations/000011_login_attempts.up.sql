@@ -0,0 +1,11 @@
+-- Failed logins into existing accounts, shown to their owners.
+CREATE TABLE "LoginAttempts" (
+    "Id" INTEGER PRIMARY KEY,
+    "UserId" INTEGER NOT NULL,
+    "Ip" TEXT NOT NULL,
+    "UserAgent" TEXT NOT NULL DEFAULT '',
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id")
+);
+
+CREATE INDEX "LoginAttempts_UserId_CreatedAt" ON "LoginAttempts" ("UserId", "CreatedAt");
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "Transactions_LedgerId_Date";
+
+ALTER TABLE "Transactions" DROP COLUMN "LedgerId";
+ALTER TABLE "TransactionCategories" DROP COLUMN "LedgerId";
+ALTER TABLE "Users" DROP COLUMN "DefaultLedgerId";
+
+DROP TABLE IF EXISTS "LedgerInvitations";
+DROP TABLE IF EXISTS "LedgerMembers";
+DROP TABLE IF EXISTS "Ledgers";
@@ -0,0 +1,48 @@
+-- A ledger owns categories and transactions and can be shared between users.
+CREATE TABLE "Ledgers" (
+    "Id" INTEGER PRIMARY KEY,
+    "Name" TEXT NOT NULL,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE "LedgerMembers" (
+    "LedgerId" INTEGER NOT NULL,
+    "UserId" INTEGER NOT NULL,
+    "Role" TEXT NOT NULL, -- owner, editor or viewer
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("LedgerId", "UserId"),
+    FOREIGN KEY ("LedgerId") REFERENCES "Ledgers"("Id"),
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id")
+);
+
+CREATE INDEX "LedgerMembers_UserId" ON "LedgerMembers" ("UserId");
+
+CREATE TABLE "LedgerInvitations" (
+    "Id" INTEGER PRIMARY KEY,
+    "LedgerId" INTEGER NOT NULL,
+    "UserId" INTEGER NOT NULL,
+    "Role" TEXT NOT NULL,
+    "InvitedBy" INTEGER NOT NULL,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY ("LedgerId") REFERENCES "Ledgers"("Id"),
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id"),
+    FOREIGN KEY ("InvitedBy") REFERENCES "Users"("Id"),
+    UNIQUE ("LedgerId", "UserId")
+);
+
+-- Every user gets a personal ledger used when a request names none. Existing
+-- users get one with the same id as theirs, which holds all their data.
+ALTER TABLE "Users" ADD COLUMN "DefaultLedgerId" INTEGER REFERENCES "Ledgers"("Id");
+
+INSERT INTO "Ledgers" ("Id", "Name") SELECT "Id", 'Personal' FROM "Users";
+INSERT INTO "LedgerMembers" ("LedgerId", "UserId", "Role") SELECT "Id", "Id", 'owner' FROM "Users";
+UPDATE "Users" SET "DefaultLedgerId" = "Id";
+
+-- "UserId" of categories and transactions now tells who created them.
+ALTER TABLE "TransactionCategories" ADD COLUMN "LedgerId" INTEGER REFERENCES "Ledgers"("Id");
+UPDATE "TransactionCategories" SET "LedgerId" = "UserId";
+
+ALTER TABLE "Transactions" ADD COLUMN "LedgerId" INTEGER REFERENCES "Ledgers"("Id");
+UPDATE "Transactions" SET "LedgerId" = "UserId";
+
+CREATE INDEX "Transactions_LedgerId_Date" ON "Transactions" ("LedgerId", "Date");
@@ -0,0 +1,10 @@
+-- Shares of a transaction owed by ledger members to the user who paid it.
+-- A settlement is a payment to the member named by its only split.
+CREATE TABLE "TransactionSplits" (
+    "TransactionId" INTEGER NOT NULL,
+    "UserId" INTEGER NOT NULL,
+    "Amount" INTEGER NOT NULL,
+    PRIMARY KEY ("TransactionId", "UserId"),
+    FOREIGN KEY ("TransactionId") REFERENCES "Transactions"("Id"),
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id")
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "TransactionTags_TagId";
+
+DROP TABLE IF EXISTS "TransactionTags";
+DROP TABLE IF EXISTS "Tags";
@@ -0,0 +1,19 @@
+-- Tags are free labels of a ledger, a transaction can have any number of them.
+CREATE TABLE "Tags" (
+    "Id" INTEGER PRIMARY KEY,
+    "LedgerId" INTEGER NOT NULL,
+    "Name" TEXT NOT NULL COLLATE NOCASE,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY ("LedgerId") REFERENCES "Ledgers"("Id"),
+    UNIQUE ("LedgerId", "Name")
+);
+
+CREATE TABLE "TransactionTags" (
+    "TransactionId" INTEGER NOT NULL,
+    "TagId" INTEGER NOT NULL,
+    PRIMARY KEY ("TransactionId", "TagId"),
+    FOREIGN KEY ("TransactionId") REFERENCES "Transactions"("Id"),
+    FOREIGN KEY ("TagId") REFERENCES "Tags"("Id")
+);
+
+CREATE INDEX "TransactionTags_TagId" ON "TransactionTags" ("TagId");
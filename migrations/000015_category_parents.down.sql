@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "TransactionCategories_ParentId";
+
+ALTER TABLE "TransactionCategories" DROP COLUMN "ParentId";
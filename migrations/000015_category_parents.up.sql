@@ -0,0 +1,4 @@
+-- Categories form a tree within their ledger, top level ones have no parent.
+ALTER TABLE "TransactionCategories" ADD COLUMN "ParentId" INTEGER REFERENCES "TransactionCategories"("Id");
+
+CREATE INDEX "TransactionCategories_ParentId" ON "TransactionCategories" ("ParentId");
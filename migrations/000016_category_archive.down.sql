@@ -0,0 +1 @@
+ALTER TABLE "TransactionCategories" DROP COLUMN "ArchivedAt";
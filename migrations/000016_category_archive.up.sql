@@ -0,0 +1,15 @@
+-- Archived categories are hidden and take no new transactions, but keep the
+-- ones they have.
+ALTER TABLE "TransactionCategories" ADD COLUMN "ArchivedAt" TIMESTAMP;
+
+-- Categories used to be deleted without looking at what referenced them.
+-- Clear those references now that foreign keys are enforced.
+UPDATE "Transactions" SET "CategoryId" = NULL
+WHERE "CategoryId" IS NOT NULL AND "CategoryId" NOT IN (SELECT "Id" FROM "TransactionCategories");
+
+DELETE FROM "Budgets" WHERE "CategoryId" NOT IN (SELECT "Id" FROM "TransactionCategories");
+
+DELETE FROM "RecurringOccurrences" WHERE "RecurringTransactionId" IN (
+    SELECT "Id" FROM "RecurringTransactions" WHERE "CategoryId" NOT IN (SELECT "Id" FROM "TransactionCategories")
+);
+DELETE FROM "RecurringTransactions" WHERE "CategoryId" NOT IN (SELECT "Id" FROM "TransactionCategories");
@@ -0,0 +1,2 @@
+ALTER TABLE "TransactionCategories" DROP COLUMN "SortOrder";
+ALTER TABLE "TransactionCategories" DROP COLUMN "Icon";
@@ -0,0 +1,3 @@
+ALTER TABLE "TransactionCategories" ADD COLUMN "Icon" TEXT NOT NULL DEFAULT '';
+-- Categories are listed by "SortOrder" and then by "Id".
+ALTER TABLE "TransactionCategories" ADD COLUMN "SortOrder" INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,22 @@
+DROP INDEX IF EXISTS "TransactionCategories_DeletedAt";
+DROP INDEX IF EXISTS "Transactions_DeletedAt";
+
+-- Whatever is in the trash is purged, as there is nothing left to mark it.
+DELETE FROM "TransactionSplits" WHERE "TransactionId" IN (
+    SELECT "Id" FROM "Transactions"
+    WHERE "DeletedAt" IS NOT NULL OR "CategoryId" IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL)
+);
+DELETE FROM "TransactionTags" WHERE "TransactionId" IN (
+    SELECT "Id" FROM "Transactions"
+    WHERE "DeletedAt" IS NOT NULL OR "CategoryId" IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL)
+);
+DELETE FROM "Transactions"
+WHERE "DeletedAt" IS NOT NULL OR "CategoryId" IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL);
+
+DELETE FROM "Budgets" WHERE "CategoryId" IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL);
+UPDATE "TransactionCategories" SET "ParentId" = NULL
+WHERE "ParentId" IN (SELECT "Id" FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL);
+DELETE FROM "TransactionCategories" WHERE "DeletedAt" IS NOT NULL;
+
+ALTER TABLE "Transactions" DROP COLUMN "DeletedAt";
+ALTER TABLE "TransactionCategories" DROP COLUMN "DeletedAt";
@@ -0,0 +1,7 @@
+-- Deleted transactions and categories stay in the trash until they are
+-- restored or purged after the retention period.
+ALTER TABLE "Transactions" ADD COLUMN "DeletedAt" TIMESTAMP;
+ALTER TABLE "TransactionCategories" ADD COLUMN "DeletedAt" TIMESTAMP;
+
+CREATE INDEX "Transactions_DeletedAt" ON "Transactions" ("DeletedAt");
+CREATE INDEX "TransactionCategories_DeletedAt" ON "TransactionCategories" ("DeletedAt");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "TransactionHistory_TransactionId";
+
+DROP TABLE IF EXISTS "TransactionHistory";
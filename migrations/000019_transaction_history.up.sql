@@ -0,0 +1,15 @@
+-- Append-only log of every change to a transaction. It keeps the ledger of
+-- the transaction and has no foreign key on it, so it outlives the purge.
+CREATE TABLE "TransactionHistory" (
+    "Id" INTEGER PRIMARY KEY,
+    "TransactionId" INTEGER NOT NULL,
+    "LedgerId" INTEGER NOT NULL,
+    "UserId" INTEGER NOT NULL, -- who made the change
+    "Action" TEXT NOT NULL, -- create, update, delete or restore
+    "Changes" TEXT NOT NULL, -- JSON object of {"old": ..., "new": ...} by field
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY ("LedgerId") REFERENCES "Ledgers"("Id"),
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id")
+);
+
+CREATE INDEX "TransactionHistory_TransactionId" ON "TransactionHistory" ("TransactionId", "Id");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "Attachments_UserId";
+DROP INDEX IF EXISTS "Attachments_Hash";
+DROP INDEX IF EXISTS "Attachments_TransactionId";
+
+DROP TABLE IF EXISTS "Attachments";
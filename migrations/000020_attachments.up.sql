@@ -0,0 +1,17 @@
+-- Files are stored once per SHA-256 hash, every attachment points at one.
+CREATE TABLE "Attachments" (
+    "Id" INTEGER PRIMARY KEY,
+    "TransactionId" INTEGER NOT NULL,
+    "UserId" INTEGER NOT NULL, -- who uploaded it, the quota is counted per user
+    "FileName" TEXT NOT NULL,
+    "ContentType" TEXT NOT NULL,
+    "Size" INTEGER NOT NULL,
+    "Hash" TEXT NOT NULL,
+    "CreatedAt" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY ("TransactionId") REFERENCES "Transactions"("Id"),
+    FOREIGN KEY ("UserId") REFERENCES "Users"("Id")
+);
+
+CREATE INDEX "Attachments_TransactionId" ON "Attachments" ("TransactionId");
+CREATE INDEX "Attachments_Hash" ON "Attachments" ("Hash");
+CREATE INDEX "Attachments_UserId" ON "Attachments" ("UserId");
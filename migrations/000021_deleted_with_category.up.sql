@@ -0,0 +1,8 @@
+-- Transactions moved to the trash along with their category remember it, so
+-- restoring the category brings back exactly those and not the ones deleted
+-- on their own in the same second.
+ALTER TABLE "Transactions" ADD COLUMN "DeletedWithCategoryId" INTEGER;
+
+UPDATE "Transactions" SET "DeletedWithCategoryId" = "CategoryId"
+WHERE "DeletedAt" IS NOT NULL
+    AND "DeletedAt" = (SELECT "DeletedAt" FROM "TransactionCategories" WHERE "TransactionCategories"."Id" = "Transactions"."CategoryId");